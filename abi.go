@@ -0,0 +1,447 @@
+package barretenberg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// The ABI of a circuit is not part of its ACIR bytecode: nargo writes it next to the bytecode in
+// the program artifact (target/<name>.json). Functions that need to know the circuit's inputs
+// and outputs therefore take the whole artifact JSON.
+
+// programArtifact is the program artifact written by `nargo compile`.
+type programArtifact struct {
+	Bytecode string `json:"bytecode"`
+	ABI      abi    `json:"abi"`
+}
+
+type abi struct {
+	Parameters []abiParameter `json:"parameters"`
+	ReturnType *abiReturnType `json:"return_type"`
+}
+
+type abiParameter struct {
+	Name       string  `json:"name"`
+	Type       abiType `json:"type"`
+	Visibility string  `json:"visibility"`
+}
+
+type abiReturnType struct {
+	ABIType    abiType `json:"abi_type"`
+	Visibility string  `json:"visibility"`
+}
+
+// abiType describes a Noir type as serialized in the ABI.
+type abiType struct {
+	Kind   string     // field, boolean, integer, array, string, struct or tuple
+	Sign   string     // integer: signed or unsigned
+	Width  uint       // integer: bit width
+	Length int        // array, string: number of elements
+	Type   *abiType   // array: element type
+	Fields []abiField // struct: named fields, tuple: unnamed elements
+	Path   string     // struct: path of the struct, e.g. "Point"
+}
+
+type abiField struct {
+	Name string
+	Type abiType
+}
+
+func (t *abiType) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Kind   string            `json:"kind"`
+		Sign   string            `json:"sign"`
+		Width  uint              `json:"width"`
+		Length int               `json:"length"`
+		Type   *abiType          `json:"type"`
+		Fields []json.RawMessage `json:"fields"`
+		Path   string            `json:"path"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = abiType{Kind: raw.Kind, Sign: raw.Sign, Width: raw.Width, Length: raw.Length, Type: raw.Type, Path: raw.Path}
+	for _, f := range raw.Fields {
+		var field abiField
+		if raw.Kind == "struct" {
+			var named struct {
+				Name string  `json:"name"`
+				Type abiType `json:"type"`
+			}
+			if err := json.Unmarshal(f, &named); err != nil {
+				return err
+			}
+			field = abiField{Name: named.Name, Type: named.Type}
+		} else if err := json.Unmarshal(f, &field.Type); err != nil {
+			return err
+		}
+		t.Fields = append(t.Fields, field)
+	}
+	switch t.Kind {
+	case "field", "boolean", "integer", "string", "struct", "tuple":
+	case "array":
+		if t.Type == nil {
+			return errors.New("abi: array type without element type")
+		}
+	default:
+		return fmt.Errorf("abi: unsupported type kind %q", t.Kind)
+	}
+	return nil
+}
+
+// String returns the Noir spelling of the type.
+func (t *abiType) String() string {
+	switch t.Kind {
+	case "integer":
+		if t.Sign == "signed" {
+			return fmt.Sprintf("i%d", t.Width)
+		}
+		return fmt.Sprintf("u%d", t.Width)
+	case "boolean":
+		return "bool"
+	case "array":
+		return fmt.Sprintf("[%s; %d]", t.Type, t.Length)
+	case "string":
+		return fmt.Sprintf("str<%d>", t.Length)
+	case "struct":
+		if t.Path != "" {
+			return t.Path
+		}
+	case "tuple":
+		elems := make([]string, len(t.Fields))
+		for i, f := range t.Fields {
+			elems[i] = f.Type.String()
+		}
+		return "(" + strings.Join(elems, ", ") + ")"
+	}
+	return t.Kind
+}
+
+// abiSlot is a single field element of a flattened ABI value.
+type abiSlot struct {
+	name string   // path of the element, e.g. "x", "point.y" or "arr[2]"
+	typ  *abiType // scalar type: field, boolean or integer, or string for a character
+}
+
+// flatten appends the field elements that make up a value of type t, in witness order.
+func (t *abiType) flatten(name string, slots []abiSlot) []abiSlot {
+	switch t.Kind {
+	case "array":
+		for i := 0; i < t.Length; i++ {
+			slots = t.Type.flatten(fmt.Sprintf("%s[%d]", name, i), slots)
+		}
+	case "string":
+		for i := 0; i < t.Length; i++ {
+			slots = append(slots, abiSlot{name: fmt.Sprintf("%s[%d]", name, i), typ: t})
+		}
+	case "struct":
+		for _, f := range t.Fields {
+			slots = f.Type.flatten(name+"."+f.Name, slots)
+		}
+	case "tuple":
+		for i, f := range t.Fields {
+			slots = f.Type.flatten(fmt.Sprintf("%s.%d", name, i), slots)
+		}
+	default:
+		slots = append(slots, abiSlot{name: name, typ: t})
+	}
+	return slots
+}
+
+// returnValueName names the return value in flattened slots.
+const returnValueName = "return"
+
+// publicInputs returns the layout of the circuit's public inputs: the public parameters in
+// declaration order followed by the return value, matching the order used in proofs.
+func (a *abi) publicInputs() []abiSlot {
+	var slots []abiSlot
+	for _, p := range a.Parameters {
+		if p.Visibility == "public" {
+			slots = p.Type.flatten(p.Name, slots)
+		}
+	}
+	return a.returnValues(slots)
+}
+
+// returnValues appends the layout of the circuit's return value.
+func (a *abi) returnValues(slots []abiSlot) []abiSlot {
+	if a.ReturnType != nil {
+		slots = a.ReturnType.ABIType.flatten(returnValueName, slots)
+	}
+	return slots
+}
+
+// bound returns the exclusive upper bound of values of the scalar type t.
+func (t *abiType) bound() *big.Int {
+	switch t.Kind {
+	case "boolean":
+		return big.NewInt(2)
+	case "integer":
+		return new(big.Int).Lsh(big.NewInt(1), t.Width)
+	case "string":
+		return big.NewInt(256)
+	}
+	return frModulus
+}
+
+// encode appends the field elements of the JSON value v, decoded with UseNumber, as a value of
+// type t. Values are encoded as nargo does for Prover.toml inputs: integers and field elements
+// as JSON numbers or decimal or 0x-prefixed hex strings, negative values in two's complement
+// for signed integers, structs as objects and tuples as arrays.
+func (t *abiType) encode(name string, v any, out [][32]byte) ([][32]byte, error) {
+	switch t.Kind {
+	case "array", "tuple":
+		items, ok := v.([]any)
+		n := t.Length
+		if t.Kind == "tuple" {
+			n = len(t.Fields)
+		}
+		if !ok || len(items) != n {
+			return nil, fmt.Errorf("%w: %s: expected an array of %d elements", ErrInvalidInput, name, n)
+		}
+		for i, item := range items {
+			elem := t.Type
+			if t.Kind == "tuple" {
+				elem = &t.Fields[i].Type
+			}
+			var err error
+			if out, err = elem.encode(fmt.Sprintf("%s[%d]", name, i), item, out); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case "struct":
+		fields, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s: expected an object", ErrInvalidInput, name)
+		}
+		for _, f := range t.Fields {
+			fv, ok := fields[f.Name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: missing field %q", ErrInvalidInput, name, f.Name)
+			}
+			var err error
+			if out, err = f.Type.encode(name+"."+f.Name, fv, out); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case "string":
+		str, ok := v.(string)
+		if !ok || len(str) != t.Length {
+			return nil, fmt.Errorf("%w: %s: expected a string of %d bytes", ErrInvalidInput, name, t.Length)
+		}
+		for i := 0; i < len(str); i++ {
+			var f [32]byte
+			f[31] = str[i]
+			out = append(out, f)
+		}
+		return out, nil
+	case "boolean":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s: expected a boolean", ErrInvalidInput, name)
+		}
+		var f [32]byte
+		if b {
+			f[31] = 1
+		}
+		return append(out, f), nil
+	}
+
+	var s string
+	switch n := v.(type) {
+	case json.Number:
+		s = n.String()
+	case string:
+		s = n
+	default:
+		return nil, fmt.Errorf("%w: %s: expected a number for %s", ErrInvalidInput, name, t)
+	}
+	x, ok := new(big.Int), false
+	if hex, isHex := strings.CutPrefix(s, "0x"); isHex {
+		x, ok = x.SetString(hex, 16)
+	} else {
+		x, ok = x.SetString(s, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: %q is not an integer", ErrInvalidInput, name, s)
+	}
+	min := new(big.Int)
+	max := t.bound()
+	if t.Kind == "integer" && t.Sign == "signed" {
+		max = new(big.Int).Lsh(big.NewInt(1), t.Width-1)
+		min.Neg(max)
+	} else if t.Kind == "field" {
+		min.Neg(frModulus)
+	}
+	if x.Cmp(min) < 0 || x.Cmp(max) >= 0 {
+		return nil, fmt.Errorf("%w: %s: %s is out of range for %s", ErrInvalidInput, name, s, t)
+	}
+	if x.Sign() < 0 {
+		if t.Kind == "field" {
+			x.Add(x, frModulus)
+		} else {
+			x.Add(x, t.bound())
+		}
+	}
+	var f [32]byte
+	x.FillBytes(f[:])
+	return append(out, f), nil
+}
+
+// encodeInputs encodes named circuit inputs into the initial witness: the values of all
+// parameters, public or private, in declaration order.
+func (a *abi) encodeInputs(inputsJson string) ([][32]byte, error) {
+	d := json.NewDecoder(strings.NewReader(inputsJson))
+	d.UseNumber()
+	var inputs map[string]any
+	if err := d.Decode(&inputs); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	var out [][32]byte
+	for _, p := range a.Parameters {
+		v, ok := inputs[p.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing parameter %q", ErrInvalidInput, p.Name)
+		}
+		var err error
+		if out, err = p.Type.encode(p.Name, v, out); err != nil {
+			return nil, err
+		}
+		delete(inputs, p.Name)
+	}
+	for name := range inputs {
+		return nil, fmt.Errorf("%w: unknown parameter %q", ErrInvalidInput, name)
+	}
+	return out, nil
+}
+
+// parseArtifact decodes a program artifact produced by `nargo compile`.
+func parseArtifact(artifactJson string) (*programArtifact, error) {
+	var a programArtifact
+	if err := json.Unmarshal([]byte(artifactJson), &a); err != nil {
+		return nil, fmt.Errorf("invalid circuit artifact: %w", err)
+	}
+	if a.Bytecode == "" {
+		return nil, errors.New("invalid circuit artifact: missing bytecode")
+	}
+	return &a, nil
+}
+
+// PublicInputRangeError reports a public input outside the range of its declared type.
+type PublicInputRangeError struct {
+	Index int      // position of the public input
+	Name  string   // ABI path of the input, e.g. "x" or "point.y"
+	Type  string   // declared Noir type, e.g. "u32"
+	Max   *big.Int // exclusive upper bound of the declared type
+}
+
+func (e *PublicInputRangeError) Error() string {
+	return fmt.Sprintf("public input %d (%s) is out of range: expected %s in [0, %s)", e.Index, e.Name, e.Type, e.Max)
+}
+
+// ValidatePublicInputs checks the public inputs against the types declared in the circuit's ABI,
+// e.g. that an input declared as u32 is below 2^32, and that their number matches the ABI.
+// artifact is the program artifact JSON written by `nargo compile`, since the ABI is not part of
+// the bytecode. It returns a *PublicInputRangeError for the first input out of range.
+func ValidatePublicInputs(artifact string, publicInputs [][32]byte) error {
+	a, err := parseArtifact(artifact)
+	if err != nil {
+		return err
+	}
+	slots := a.ABI.publicInputs()
+	if len(publicInputs) != len(slots) {
+		return fmt.Errorf("expected %d public inputs, got %d", len(slots), len(publicInputs))
+	}
+	for i, slot := range slots {
+		max := slot.typ.bound()
+		if new(big.Int).SetBytes(publicInputs[i][:]).Cmp(max) >= 0 {
+			return &PublicInputRangeError{Index: i, Name: slot.name, Type: slot.typ.String(), Max: max}
+		}
+	}
+	return nil
+}
+
+// publicInputsFor decodes the public inputs of proof and checks their number against the ABI.
+func (a *abi) publicInputsFor(proof []byte) ([][32]byte, error) {
+	p, err := decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	if want := len(a.publicInputs()); len(p.publicInputs) != want {
+		return nil, fmt.Errorf("proof has %d public inputs, circuit ABI declares %d", len(p.publicInputs), want)
+	}
+	return p.publicInputs, nil
+}
+
+// ErrVerificationFailed is returned when a proof is well-formed but does not verify.
+var ErrVerificationFailed = errors.New("proof verification failed")
+
+// verificationError classifies an error of VerifyUltraHonkErr: a rejected proof wraps both
+// ErrVerificationFailed and the backend error, so callers can still tell why it was rejected.
+func verificationError(err error) error {
+	if errors.Is(err, ErrInvalidProof) || errors.Is(err, ErrVkMismatch) {
+		return fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+	return err
+}
+
+// DecodedProof holds the public values of a verified proof, decoded with the circuit's ABI.
+// Values are flattened into field elements in ABI order, e.g. a [u8; 3] parameter yields 3 elements.
+type DecodedProof struct {
+	PublicInputs map[string][]Fr // public parameters, by name
+	ReturnValue  []Fr            // nil if the circuit returns nothing
+	ReturnType   string          // Noir type of the return value, e.g. "[u8; 2]", empty if there is none
+	ReturnNames  []string        // ABI path of each element of ReturnValue, e.g. "return[0]" or "return.x"
+	VK           []byte          // verification key derived from the bytecode
+}
+
+// VerifyAndDecode derives the verification key from the artifact's bytecode, verifies the proof
+// and decodes its public inputs and return value using the ABI.
+// artifact is the program artifact JSON written by `nargo compile`.
+// A proof that does not verify yields an error wrapping ErrVerificationFailed and the error of
+// VerifyUltraHonkErr.
+func VerifyAndDecode(proof []byte, artifact string, settings ProofSystemSettings) (*DecodedProof, error) {
+	a, err := parseArtifact(artifact)
+	if err != nil {
+		return nil, err
+	}
+	publicInputs, err := a.ABI.publicInputsFor(proof)
+	if err != nil {
+		return nil, err
+	}
+	vk, err := GetVkUltraHonk(a.Bytecode, settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyUltraHonkErr(proof, vk, settings); err != nil {
+		return nil, verificationError(err)
+	}
+
+	d := &DecodedProof{PublicInputs: make(map[string][]Fr), VK: vk}
+	next := func(n int) []Fr {
+		values := make([]Fr, n)
+		for i := range values {
+			values[i] = Fr(publicInputs[i])
+		}
+		publicInputs = publicInputs[n:]
+		return values
+	}
+	for _, p := range a.ABI.Parameters {
+		if p.Visibility == "public" {
+			d.PublicInputs[p.Name] = next(len(p.Type.flatten(p.Name, nil)))
+		}
+	}
+	if slots := a.ABI.returnValues(nil); len(slots) > 0 {
+		d.ReturnValue = next(len(slots))
+		d.ReturnType = a.ABI.ReturnType.ABIType.String()
+		for _, slot := range slots {
+			d.ReturnNames = append(d.ReturnNames, slot.name)
+		}
+	}
+	return d, nil
+}
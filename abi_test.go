@@ -0,0 +1,115 @@
+package barretenberg
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+const testArtifact = `{
+	"noir_version": "1.0.0-beta.19",
+	"abi": {
+		"parameters": [
+			{"name": "x", "type": {"kind": "field"}, "visibility": "private"},
+			{"name": "limit", "type": {"kind": "integer", "sign": "unsigned", "width": 32}, "visibility": "public"},
+			{"name": "point", "type": {"kind": "struct", "path": "Point", "fields": [
+				{"name": "x", "type": {"kind": "field"}},
+				{"name": "ok", "type": {"kind": "boolean"}}
+			]}, "visibility": "public"}
+		],
+		"return_type": {"abi_type": {"kind": "array", "length": 2, "type": {"kind": "integer", "sign": "unsigned", "width": 8}}, "visibility": "public"},
+		"error_types": {}
+	},
+	"bytecode": "H4sIAAAAAAAA/wEAAP//AAAAAAAAAAA="
+}`
+
+func TestABIPublicInputs(t *testing.T) {
+	a, err := parseArtifact(testArtifact)
+	if err != nil {
+		t.Fatalf("failed to parse artifact: %v", err)
+	}
+	var names []string
+	for _, s := range a.ABI.publicInputs() {
+		names = append(names, s.name)
+	}
+	if typ := a.ABI.ReturnType.ABIType.String(); typ != "[u8; 2]" {
+		t.Fatalf("unexpected return type %q", typ)
+	}
+	want := []string{"limit", "point.x", "point.ok", "return[0]", "return[1]"}
+	if len(names) != len(want) {
+		t.Fatalf("unexpected public inputs %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("unexpected public inputs %v, want %v", names, want)
+		}
+	}
+}
+
+func TestValidatePublicInputs(t *testing.T) {
+	inputs := [][32]byte{testField(7), testField(3), testField(1), testField(2), testField(255)}
+	if err := ValidatePublicInputs(testArtifact, inputs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inputs[2] = testField(2) // point.ok is a bool
+	var rangeErr *PublicInputRangeError
+	if err := ValidatePublicInputs(testArtifact, inputs); !errors.As(err, &rangeErr) || rangeErr.Index != 2 {
+		t.Fatalf("expected range error at index 2, got %v", err)
+	}
+
+	inputs[2] = testField(1)
+	inputs[0][27] = 1 // limit = 2^32 + 7
+	if err := ValidatePublicInputs(testArtifact, inputs); !errors.As(err, &rangeErr) || rangeErr.Index != 0 || rangeErr.Type != "u32" {
+		t.Fatalf("expected u32 range error at index 0, got %v", err)
+	}
+
+	if err := ValidatePublicInputs(testArtifact, inputs[:2]); err == nil {
+		t.Fatalf("expected error for a wrong number of public inputs")
+	}
+}
+
+func TestExtractReturnValues(t *testing.T) {
+	p := testProof([][32]byte{testField(7), testField(3), testField(1), testField(2), testField(255)}, false)
+	// Two public parameters, x of 3 elements, and the return value.
+	info := &acirInfo{PublicParameters: []uint32{1, 2, 3}, ReturnValues: []uint32{6, 7}}
+	returns, err := info.returnValuesOf(p.encode())
+	if err != nil {
+		t.Fatalf("failed to extract return values: %v", err)
+	}
+	if len(returns) != 2 || returns[0] != testField(2) || returns[1] != testField(255) {
+		t.Fatalf("unexpected return values: %x", returns)
+	}
+
+	p.publicInputs = p.publicInputs[:4]
+	if _, err := info.returnValuesOf(p.encode()); err == nil {
+		t.Fatalf("expected error for a proof not matching the circuit")
+	}
+}
+
+func TestEncodeInputs(t *testing.T) {
+	a, err := parseArtifact(testArtifact)
+	if err != nil {
+		t.Fatalf("failed to parse artifact: %v", err)
+	}
+	witness, err := a.ABI.encodeInputs(`{"x": "-1", "limit": "0x10", "point": {"x": 5, "ok": true}}`)
+	if err != nil {
+		t.Fatalf("failed to encode inputs: %v", err)
+	}
+	minusOne := new(big.Int).Sub(frModulus, big.NewInt(1))
+	if len(witness) != 4 || new(big.Int).SetBytes(witness[0][:]).Cmp(minusOne) != 0 ||
+		witness[1] != testField(16) || witness[2] != testField(5) || witness[3] != testField(1) {
+		t.Fatalf("unexpected witness: %x", witness)
+	}
+
+	for _, inputs := range []string{
+		`{"x": 1, "limit": 4294967296, "point": {"x": 5, "ok": true}}`,
+		`{"x": 1, "limit": 1, "point": {"x": 5}}`,
+		`{"x": 1, "limit": 1, "point": {"x": 5, "ok": true}, "y": 2}`,
+		`{"x": 1.5, "limit": 1, "point": {"x": 5, "ok": true}}`,
+	} {
+		if _, err := a.ABI.encodeInputs(inputs); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", inputs, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+)
+
+// acirInfo describes the main function of an ACIR program.
+type acirInfo struct {
+	NumOpcodes          uint32   `json:"num_opcodes"`
+	CurrentWitnessIndex uint32   `json:"current_witness_index"`
+	PrivateParameters   []uint32 `json:"private_parameters"`
+	PublicParameters    []uint32 `json:"public_parameters"`
+	ReturnValues        []uint32 `json:"return_values"`
+}
+
+// getACIRInfo inspects the bytecode without going through the prover.
+func getACIRInfo(bytecode string) (*acirInfo, error) {
+	cBytecode := C.CString(bytecode)
+	defer C.free(unsafe.Pointer(cBytecode))
+
+	r := C.bb_acir_info(cBytecode)
+	data, err := resultToBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	var info acirInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid ACIR info from backend: %w", err)
+	}
+	return &info, nil
+}
+
+// publicInputIndices returns the witnesses exposed as public inputs, in proof order:
+// the public parameters followed by the return values, each sorted by witness index.
+func (info *acirInfo) publicInputIndices() []int {
+	indices := make([]int, 0, len(info.PublicParameters)+len(info.ReturnValues))
+	for _, w := range info.PublicParameters {
+		indices = append(indices, int(w))
+	}
+	for _, w := range info.ReturnValues {
+		indices = append(indices, int(w))
+	}
+	return indices
+}
+
+// PublicInputIndices returns the witness indices of the circuit's public inputs, in the order
+// they appear in proofs: the public parameters followed by the return values.
+// Indexing a full witness with them yields the public inputs expected by the verifier.
+func PublicInputIndices(bytecode string) ([]int, error) {
+	info, err := getACIRInfo(bytecode)
+	if err != nil {
+		return nil, err
+	}
+	return info.publicInputIndices(), nil
+}
+
+// ExtractReturnValues returns the circuit's return values from the public inputs of a proof,
+// flattened into field elements in ABI order. They follow the public parameters.
+// The return value witnesses are read from the bytecode, without going through the prover.
+func ExtractReturnValues(proof []byte, bytecode string) ([][32]byte, error) {
+	info, err := getACIRInfo(bytecode)
+	if err != nil {
+		return nil, err
+	}
+	return info.returnValuesOf(proof)
+}
+
+// returnValuesOf returns the return values from the public inputs of proof.
+func (info *acirInfo) returnValuesOf(proof []byte) ([][32]byte, error) {
+	p, err := decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	if want := len(info.publicInputIndices()); len(p.publicInputs) != want {
+		return nil, fmt.Errorf("proof has %d public inputs, circuit declares %d", len(p.publicInputs), want)
+	}
+	return p.publicInputs[len(p.publicInputs)-len(info.ReturnValues):], nil
+}
+
+// CircuitStats describes the size of a circuit.
+type CircuitStats struct {
+	GateCount        uint64 `json:"gate_count"`       // gates of the circuit built by the backend
+	SubgroupSize     uint64 `json:"subgroup_size"`    // gate count rounded up to a power of two, the size the SRS must cover
+	PublicInputCount uint32 `json:"-"`                // public inputs of the circuit, excluding those added by the backend
+	NumAcirOpcodes   uint32 `json:"num_acir_opcodes"` // opcodes of the ACIR program
+}
+
+// CircuitInfo returns the size of the circuit as the backend builds it with the given settings,
+// which affect the gate count. It builds the circuit without computing any proving key or proof,
+// so it is much cheaper than proving.
+func CircuitInfo(bytecode string, settings ProofSystemSettings) (CircuitStats, error) {
+	var stats CircuitStats
+	info, err := getACIRInfo(bytecode)
+	if err != nil {
+		return stats, err
+	}
+
+	cBytecode := C.CString(bytecode)
+	defer C.free(unsafe.Pointer(cBytecode))
+
+	cSettings, err := settingsCString(settings)
+	if err != nil {
+		return stats, err
+	}
+	defer C.free(unsafe.Pointer(cSettings))
+
+	backendMu.RLock()
+	r := C.bb_circuit_info(cBytecode, cSettings)
+	backendMu.RUnlock()
+	data, err := resultToBytes(r)
+	if err != nil {
+		return stats, err
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return stats, fmt.Errorf("invalid circuit info from backend: %w", err)
+	}
+	stats.PublicInputCount = uint32(len(info.publicInputIndices()))
+	return stats, nil
+}
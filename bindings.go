@@ -7,10 +7,13 @@ package barretenberg
 */
 import "C"
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"unsafe"
 )
 
@@ -25,10 +28,21 @@ const (
 
 // ProofSystemSettings defines the settings for the UltraHonk proof system.
 type ProofSystemSettings struct {
-	IpaAccumulation           bool           `json:"ipa_accumulation"`           // true for recursive/rollup proofs
+	IpaAccumulation           bool           `json:"ipa_accumulation"`            // true for recursive/rollup proofs
 	OracleHashType            OracleHashType `json:"oracle_hash_type"`            // Use HashPoseidon2, HashKeccak, or HashBlake2s
-	DisableZk                 bool           `json:"disable_zk"`                 // true for faster, non-private proofs
+	DisableZk                 bool           `json:"disable_zk"`                  // true for faster, non-private proofs
 	OptimizedSolidityVerifier bool           `json:"optimized_solidity_verifier"` // true for gas-optimized EVM verification
+	VKHashType                OracleHashType `json:"vk_hash_type,omitempty"`      // Hash used by SolidityVKHash; empty means OracleHashType
+}
+
+// vkHashType returns the hash SolidityVKHash uses, which defaults to the oracle hash when unset.
+// It only applies to SolidityVKHash: the VK hash bb embeds in the verifiers it generates always
+// follows the oracle hash.
+func (s ProofSystemSettings) vkHashType() OracleHashType {
+	if s.VKHashType == "" {
+		return s.OracleHashType
+	}
+	return s.VKHashType
 }
 
 // DefaultSettings returns the default settings for UltraHonk (Poseidon2).
@@ -41,6 +55,36 @@ func DefaultSettings() ProofSystemSettings {
 	}
 }
 
+// ErrInvalidSettings is wrapped by the errors of ProofSystemSettings.Validate.
+var ErrInvalidSettings = errors.New("invalid proof system settings")
+
+// Validate reports settings that the backend would reject or that can't produce a usable proof:
+//   - OracleHashType must be one of the Hash constants;
+//   - VKHashType must be empty, HashKeccak or HashPoseidon2, the hashes SolidityVKHash supports;
+//   - OptimizedSolidityVerifier requires the Keccak oracle hash, the one the EVM verifier uses;
+//   - IpaAccumulation requires the Poseidon2 oracle hash, as the accumulated proofs are verified
+//     recursively, in circuit.
+//
+// Every function taking settings validates them before calling the backend. Errors wrap ErrInvalidSettings.
+func (s ProofSystemSettings) Validate() error {
+	known := func(h OracleHashType) bool {
+		return h == HashPoseidon2 || h == HashKeccak || h == HashBlake2s
+	}
+	if !known(s.OracleHashType) {
+		return fmt.Errorf("%w: unknown oracle hash %q", ErrInvalidSettings, s.OracleHashType)
+	}
+	if s.VKHashType != "" && s.VKHashType != HashKeccak && s.VKHashType != HashPoseidon2 {
+		return fmt.Errorf("%w: unknown VK hash %q", ErrInvalidSettings, s.VKHashType)
+	}
+	if s.OptimizedSolidityVerifier && s.OracleHashType != HashKeccak {
+		return fmt.Errorf("%w: the optimized solidity verifier requires the %q oracle hash, got %q", ErrInvalidSettings, HashKeccak, s.OracleHashType)
+	}
+	if s.IpaAccumulation && s.OracleHashType != HashPoseidon2 {
+		return fmt.Errorf("%w: IPA accumulation requires the recursion friendly %q oracle hash, got %q", ErrInvalidSettings, HashPoseidon2, s.OracleHashType)
+	}
+	return nil
+}
+
 // BackendType represents the type of Barretenberg backend to use.
 type BackendType string
 
@@ -49,10 +93,33 @@ const (
 	BackendNative BackendType = "native"
 )
 
+// backendMu orders backend calls with changes to the backend. Calls into the backend hold it
+// for reading and can run concurrently: the shim runs one backend command at a time itself.
+// SetBackendType and ResetBackend hold it for writing, so they wait for in-flight calls and
+// the backend type is never read by the shim while it is being changed.
+var backendMu sync.RWMutex
+
 // SetBackendType sets the backend type globally via environment variable.
-// Note: This must be called BEFORE any proving/verification functions to take effect.
+// It waits for in-flight calls into the backend to complete, then shuts the current backend
+// down like ResetBackend, so the next call uses the new backend type.
 func SetBackendType(t BackendType) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
 	os.Setenv("BB_BACKEND_TYPE", string(t))
+	if GetBackendType() == BackendPipe {
+		capturePipeStderr()
+	}
+	C.bb_reset_backend()
+}
+
+// ResetBackend shuts down the current backend, terminating the `bb` subprocess in pipe mode.
+// The next proving/verification call starts a new backend of the configured type. An SRS loaded
+// with InitSRSFromFile or InitSRSFromBytes is discarded.
+// It waits for in-flight calls into the backend to complete.
+func ResetBackend() {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	C.bb_reset_backend()
 }
 
 // GetBackendType returns the currently configured backend type.
@@ -66,13 +133,8 @@ func GetBackendType() BackendType {
 
 // Result is a helper to convert C.BBResult to Go types
 func resultToBytes(r C.BBResult) ([]byte, error) {
-	if !bool(r.ok) {
-		if r.err == nil {
-			return nil, errors.New("unknown error from backend")
-		}
-		msg := C.GoString(r.err)
-		C.bb_free_err(r.err)
-		return nil, errors.New(msg)
+	if err := resultErr(r); err != nil {
+		return nil, err
 	}
 	defer C.bb_free_bytes(r.data)
 	if r.data.ptr == nil || r.data.len == 0 {
@@ -81,72 +143,339 @@ func resultToBytes(r C.BBResult) ([]byte, error) {
 	return C.GoBytes(unsafe.Pointer(r.data.ptr), C.int(r.data.len)), nil
 }
 
+// resultErr returns the *BackendError held by a failed C.BBResult, freeing the error string.
+// It returns nil if the result is ok, in which case the caller owns r.data.
+func resultErr(r C.BBResult) error {
+	if bool(r.ok) {
+		return nil
+	}
+	if r.err == nil {
+		return newBackendError("unknown error from backend")
+	}
+	msg := C.GoString(r.err)
+	C.bb_free_err(r.err)
+	e := newBackendError(msg)
+	// Failures of the pipe backend carry the stderr of the failed command.
+	if r.data.ptr != nil {
+		e.Stderr = C.GoStringN((*C.char)(unsafe.Pointer(r.data.ptr)), C.int(r.data.len))
+		C.bb_free_bytes(r.data)
+	}
+	return e
+}
+
+// settingsCString validates the settings and encodes them as the JSON C string expected by the
+// native layer. The caller must free the returned string.
+func settingsCString(settings ProofSystemSettings) (*C.char, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	settingsData, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	return C.CString(string(settingsData)), nil
+}
+
 // InitSRS initializes the SRS from the bytecode
 func InitSRS(bytecode string) error {
 	cBytecode := C.CString(bytecode)
 	defer C.free(unsafe.Pointer(cBytecode))
 
+	backendMu.RLock()
 	r := C.bb_init_srs_from_bytecode(cBytecode)
+	backendMu.RUnlock()
 	_, err := resultToBytes(r)
 	return err
 }
 
+// Warmup starts the backend and proves a small built-in circuit with the given settings, so the
+// first real proof doesn't pay for the backend's lazy initialization: starting the `bb`
+// subprocess in pipe mode, loading the SRS, and first use of the allocator and thread pool.
+// Call it e.g. before reporting an instance as ready. Proofs of circuits larger than the
+// built-in one may still load more SRS points on first use.
+func Warmup(settings ProofSystemSettings) error {
+	cSettings, err := settingsCString(settings)
+	if err != nil {
+		return err
+	}
+	defer C.free(unsafe.Pointer(cSettings))
+
+	backendMu.RLock()
+	r := C.bb_warmup(cSettings)
+	backendMu.RUnlock()
+	_, err = resultToBytes(r)
+	return err
+}
+
 // ProveUltraHonk generates an UltraHonk proof for the given bytecode, witness JSON, and settings.
 // bytecode: base64 encoded gzipped bytecode from Nargo
 // witnessJson: JSON string like `{"witness": ["0x...", "0x..."]}`
 // settings: ProofSystemSettings struct
+// If profiling is enabled with SetProfileOutput, the timing of each phase is reported.
 func ProveUltraHonk(bytecode string, witnessJson string, settings ProofSystemSettings) ([]byte, error) {
+	return ProveUltraHonkContext(context.Background(), bytecode, witnessJson, settings)
+}
+
+// ProveUltraHonkContext is like ProveUltraHonk, returning ctx.Err() as soon as ctx is done.
+// A native call can't be interrupted, so proving runs on its own goroutine: when ctx is done
+// first, the proof is still computed in the background and then released, and the backend
+// stays busy with it until then.
+func ProveUltraHonkContext(ctx context.Context, bytecode string, witnessJson string, settings ProofSystemSettings) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		proof []byte
+		err   error
+	}
+	// Buffered, so the goroutine can always deliver its result and exit.
+	done := make(chan result, 1)
+	go func() {
+		prof := startProfile("prove_ultrahonk")
+		defer prof.done()
+
+		r, err := proveUltraHonk(bytecode, witnessJson, settings, prof)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		// resultToBytes frees the native result, even if nobody is waiting for it anymore.
+		proof, err := resultToBytes(r)
+		prof.mark("copy_proof")
+		done <- result{proof, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.proof, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// proveUltraHonk runs the native prover and returns its raw result, which the caller must release.
+func proveUltraHonk(bytecode string, witnessJson string, settings ProofSystemSettings, prof *phaseTimer) (C.BBResult, error) {
 	cBytecode := C.CString(bytecode)
 	defer C.free(unsafe.Pointer(cBytecode))
 
 	cWJSON := C.CString(witnessJson)
 	defer C.free(unsafe.Pointer(cWJSON))
 
-	settingsData, err := json.Marshal(settings)
+	cSettings, err := settingsCString(settings)
 	if err != nil {
-		return nil, err
+		return C.BBResult{}, err
 	}
-	cSettings := C.CString(string(settingsData))
 	defer C.free(unsafe.Pointer(cSettings))
+	prof.mark("encode_inputs")
 
+	backendMu.RLock()
 	r := C.bb_prove_ultrahonk(cBytecode, cWJSON, cSettings)
+	backendMu.RUnlock()
+	prof.mark("native_prove")
+	return r, nil
+}
+
+// ProveUltraHonkWithVk is like ProveUltraHonk, reusing vk as returned by GetVkUltraHonk for the
+// same bytecode and settings instead of computing it again, which saves about the cost of
+// GetVkUltraHonk on every proof. It is the stateless counterpart of ProverContext.
+// The key is checked against the circuit size and public inputs reported by CircuitInfo, so a
+// key of another circuit or computed with other settings fails with ErrVkMismatch, but a key of
+// a circuit with the same shape can't be told apart and yields a proof that doesn't verify.
+// An empty vk is computed by the backend, as ProveUltraHonk does.
+func ProveUltraHonkWithVk(bytecode string, witnessJson string, vk []byte, settings ProofSystemSettings) ([]byte, error) {
+	if len(vk) == 0 {
+		return ProveUltraHonk(bytecode, witnessJson, settings)
+	}
+	if err := checkVkMatchesCircuit(bytecode, vk, settings); err != nil {
+		return nil, err
+	}
+
+	cBytecode := C.CString(bytecode)
+	defer C.free(unsafe.Pointer(cBytecode))
+
+	cWJSON := C.CString(witnessJson)
+	defer C.free(unsafe.Pointer(cWJSON))
+
+	cSettings, err := settingsCString(settings)
+	if err != nil {
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(cSettings))
+
+	backendMu.RLock()
+	r := C.bb_prove_ultrahonk_with_vk(cBytecode, cWJSON, (*C.uint8_t)(unsafe.Pointer(&vk[0])), C.uintptr_t(len(vk)), cSettings)
+	backendMu.RUnlock()
 	return resultToBytes(r)
 }
 
+// checkVkMatchesCircuit checks the header of vk against the circuit the backend builds from bytecode.
+func checkVkMatchesCircuit(bytecode string, vk []byte, settings ProofSystemSettings) error {
+	h, err := parseVKHeader(vk)
+	if err != nil {
+		return err
+	}
+	stats, err := CircuitInfo(bytecode, settings)
+	if err != nil {
+		return err
+	}
+	if uint64(1)<<h.logCircuitSize != stats.SubgroupSize {
+		return &BackendError{Code: ErrVkMismatch, Message: fmt.Sprintf("verification key is for a circuit of size 2^%d, the circuit has size %d", h.logCircuitSize, stats.SubgroupSize)}
+	}
+	want := uint64(stats.PublicInputCount) + pairingPointsSize
+	if settings.IpaAccumulation {
+		want += ipaClaimSize
+	}
+	if h.numPublicInputs != want {
+		return &BackendError{Code: ErrVkMismatch, Message: fmt.Sprintf("verification key has %d public inputs, expected %d for the circuit and settings", h.numPublicInputs, want)}
+	}
+	return nil
+}
+
+// witnessChunkValues is the number of witness values ProveUltraHonkLazyWitness passes to the
+// native layer at a time, 128 KiB.
+var witnessChunkValues = 4096
+
+// ProveUltraHonkLazyWitness is like ProveUltraHonk, with the witness values produced on demand by
+// witnessFn, which is called with indices 0, 1, 2, ... until it returns false.
+// The values are streamed to the native layer in chunks as witnessFn produces them, so neither
+// the witness JSON nor a complete copy of the witness is built in Go. The native layer still
+// holds the complete witness, as the backend needs all of it to prove.
+func ProveUltraHonkLazyWitness(bytecode string, witnessFn func(index int) ([32]byte, bool), settings ProofSystemSettings) ([]byte, error) {
+	prof := startProfile("prove_ultrahonk_lazy_witness")
+	defer prof.done()
+
+	cSettings, err := settingsCString(settings)
+	if err != nil {
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(cSettings))
+
+	witness := C.bb_witness_new()
+	if witness == nil {
+		return nil, errors.New("failed to allocate the witness")
+	}
+	defer C.bb_witness_free(witness)
+
+	chunk := make([]byte, 0, witnessChunkValues*fieldSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		r := C.bb_witness_append(witness, (*C.uint8_t)(unsafe.Pointer(&chunk[0])), C.uintptr_t(len(chunk)/fieldSize))
+		chunk = chunk[:0]
+		_, err := resultToBytes(r)
+		return err
+	}
+	n := 0
+	for ; ; n++ {
+		v, ok := witnessFn(n)
+		if !ok {
+			break
+		}
+		if len(chunk) == cap(chunk) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		chunk = append(chunk, v[:]...)
+	}
+	if n == 0 {
+		return nil, errors.New("empty witness")
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	prof.mark("generate_witness")
+
+	cBytecode := C.CString(bytecode)
+	defer C.free(unsafe.Pointer(cBytecode))
+	prof.mark("encode_inputs")
+
+	backendMu.RLock()
+	r := C.bb_prove_ultrahonk_witness(cBytecode, witness, cSettings)
+	backendMu.RUnlock()
+	prof.mark("native_prove")
+
+	proof, err := resultToBytes(r)
+	prof.mark("copy_proof")
+	return proof, err
+}
+
 // GetVkUltraHonk returns the verification key for the given bytecode and settings.
 func GetVkUltraHonk(bytecode string, settings ProofSystemSettings) ([]byte, error) {
 	cBytecode := C.CString(bytecode)
 	defer C.free(unsafe.Pointer(cBytecode))
 
-	settingsData, err := json.Marshal(settings)
+	cSettings, err := settingsCString(settings)
 	if err != nil {
 		return nil, err
 	}
-	cSettings := C.CString(string(settingsData))
 	defer C.free(unsafe.Pointer(cSettings))
 
+	backendMu.RLock()
 	r := C.bb_get_vk_ultrahonk(cBytecode, cSettings)
+	backendMu.RUnlock()
 	return resultToBytes(r)
 }
 
 // VerifyUltraHonk verifies a proof using the verification key and settings.
+// Proofs exceeding the limit set by SetVerifierMemoryLimit are rejected without being verified.
+// Use VerifyUltraHonkErr to learn why a proof is rejected.
 func VerifyUltraHonk(proof []byte, vk []byte, settings ProofSystemSettings) bool {
-	if len(proof) == 0 || len(vk) == 0 {
-		return false
+	return VerifyUltraHonkErr(proof, vk, settings) == nil
+}
+
+// VerifyUltraHonkErr verifies a proof like VerifyUltraHonk, returning nil if it is valid and the
+// reason otherwise. A proof that is rejected by the backend yields a *BackendError with code
+// ErrInvalidProof, or ErrVkMismatch if the proof's public inputs don't fit the verification key
+// and settings, as reported by CheckVerifyCompatibility.
+func VerifyUltraHonkErr(proof []byte, vk []byte, settings ProofSystemSettings) error {
+	if len(proof) == 0 {
+		return errors.New("empty proof")
 	}
+	return verifyUltraHonk((*C.uint8_t)(unsafe.Pointer(&proof[0])), len(proof), vk, settings)
+}
 
-	settingsData, err := json.Marshal(settings)
+// verifyUltraHonk verifies a proof held in memory readable by C, either Go or native owned.
+func verifyUltraHonk(proof *C.uint8_t, proofLen int, vk []byte, settings ProofSystemSettings) error {
+	if proof == nil || proofLen == 0 {
+		return errors.New("empty proof")
+	}
+	if len(vk) == 0 {
+		return errors.New("empty verification key")
+	}
+	proofBytes := unsafe.Slice((*byte)(unsafe.Pointer(proof)), proofLen)
+	if err := CheckVerifierMemoryLimit(proofBytes, vk); err != nil {
+		return err
+	}
+
+	cSettings, err := settingsCString(settings)
 	if err != nil {
-		return false
+		return err
 	}
-	cSettings := C.CString(string(settingsData))
 	defer C.free(unsafe.Pointer(cSettings))
-	
-	return bool(C.bb_verify_ultrahonk(
+	return verifyPrepared(proofBytes, vk, cSettings, settings)
+}
+
+// verifyPrepared runs the native verifier on a proof and key held in memory readable by C,
+// with the settings already checked and encoded as cSettings.
+func verifyPrepared(proof []byte, vk []byte, cSettings *C.char, settings ProofSystemSettings) error {
+	backendMu.RLock()
+	r := C.bb_verify_ultrahonk_err(
 		(*C.uint8_t)(unsafe.Pointer(&proof[0])),
 		C.uintptr_t(len(proof)),
 		(*C.uint8_t)(unsafe.Pointer(&vk[0])),
 		C.uintptr_t(len(vk)),
 		cSettings,
-	))
+	)
+	backendMu.RUnlock()
+	_, err := resultToBytes(r)
+	var be *BackendError
+	if errors.As(err, &be) && (be.Code == ErrInvalidProof || be.Code == ErrUnknown) {
+		// The checks are only run on failure, to explain it: they never reject a valid proof.
+		if cerr := CheckVerifyCompatibility(proof, vk, settings); cerr != nil {
+			return &BackendError{Code: ErrVkMismatch, Message: cerr.Error(), Stderr: be.Stderr}
+		}
+	}
+	return err
 }
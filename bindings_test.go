@@ -1,13 +1,23 @@
 package barretenberg
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"math/big"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
-func TestProveVerify(t *testing.T) {
+// testCircuit loads the compiled test circuit and a satisfying witness (x = 3, y = 9).
+func testCircuit(t testing.TB) (bytecode string, witnessJSON string) {
+	t.Helper()
 	// Read bytecode from testdata/circuit/target/circuit.json
 	data, err := os.ReadFile("testdata/circuit/target/circuit.json")
 	if err != nil {
@@ -29,19 +39,24 @@ func TestProveVerify(t *testing.T) {
 			"0x0000000000000000000000000000000000000000000000000000000000000009",
 		},
 	}
-	witnessJSON, _ := json.Marshal(witness)
+	w, _ := json.Marshal(witness)
+	return circuit.Bytecode, string(w)
+}
+
+func TestProveVerify(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
 
 	settings := DefaultSettings()
 
 	// 1. Prove
-	proof, err := ProveUltraHonk(circuit.Bytecode, string(witnessJSON), settings)
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
 	if err != nil {
 		t.Fatalf("failed to prove: %v", err)
 	}
 	t.Logf("Proof length: %d", len(proof))
 
 	// 2. Get VK
-	vk, err := GetVkUltraHonk(circuit.Bytecode, settings)
+	vk, err := GetVkUltraHonk(bytecode, settings)
 	if err != nil {
 		t.Fatalf("failed to get VK: %v", err)
 	}
@@ -55,6 +70,235 @@ func TestProveVerify(t *testing.T) {
 	t.Logf("Verification success!")
 }
 
+func TestVerifyUltraHonkErr(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	if err := VerifyUltraHonkErr(proof, vk, settings); err != nil {
+		t.Fatalf("valid proof rejected: %v", err)
+	}
+
+	p, err := decodeProof(proof)
+	if err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	p.proof[len(p.proof)-1][31] ^= 1
+	if err := VerifyUltraHonkErr(p.encode(), vk, settings); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("expected ErrInvalidProof for a tampered proof, got %v", err)
+	}
+
+	ipaSettings := settings
+	ipaSettings.IpaAccumulation = true
+	if err := VerifyUltraHonkErr(proof, vk, ipaSettings); !errors.Is(err, ErrVkMismatch) {
+		t.Fatalf("expected ErrVkMismatch for mismatched settings, got %v", err)
+	}
+	if err := VerifyUltraHonkErr(nil, vk, settings); err == nil {
+		t.Fatalf("expected error for an empty proof")
+	}
+}
+
+// TestConcurrentUse runs proofs and verifications concurrently with backend resets.
+// Run it with -race to check the package's locking.
+func TestConcurrentUse(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			p, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+			if err == nil && !VerifyUltraHonk(p, vk, settings) {
+				err = errors.New("concurrent proof failed verification")
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := VerifyUltraHonkErr(proof, vk, settings); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 4; i++ {
+			SetBackendType(GetBackendType())
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+
+	var mu sync.Mutex
+	var lines []string
+	SetLogHandler(func(level LogLevel, msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if level == LogInfo {
+			lines = append(lines, msg)
+		}
+	})
+	defer SetLogHandler(nil)
+
+	if _, err := ProveUltraHonk(bytecode, witnessJSON, DefaultSettings()); err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, line := range lines {
+		if line == "constructing proof" {
+			return
+		}
+	}
+	t.Fatalf("expected a proving milestone, got %q", lines)
+}
+
+func TestSettingsValidate(t *testing.T) {
+	if err := DefaultSettings().Validate(); err != nil {
+		t.Fatalf("default settings are invalid: %v", err)
+	}
+	keccak := DefaultSettings()
+	keccak.OracleHashType = HashKeccak
+	keccak.OptimizedSolidityVerifier = true
+	if err := keccak.Validate(); err != nil {
+		t.Fatalf("unexpected error for optimized keccak settings: %v", err)
+	}
+
+	bad := map[string]func(s *ProofSystemSettings){
+		"empty oracle":         func(s *ProofSystemSettings) { s.OracleHashType = "" },
+		"unknown oracle":       func(s *ProofSystemSettings) { s.OracleHashType = "sha256" },
+		"unknown VK hash":      func(s *ProofSystemSettings) { s.VKHashType = "sha256" },
+		"blake2s VK hash":      func(s *ProofSystemSettings) { s.VKHashType = HashBlake2s },
+		"optimized, poseidon2": func(s *ProofSystemSettings) { s.OptimizedSolidityVerifier = true },
+		"IPA, keccak": func(s *ProofSystemSettings) {
+			s.IpaAccumulation = true
+			s.OracleHashType = HashKeccak
+		},
+	}
+	for name, modify := range bad {
+		settings := DefaultSettings()
+		modify(&settings)
+		if err := settings.Validate(); !errors.Is(err, ErrInvalidSettings) {
+			t.Errorf("%s: expected ErrInvalidSettings, got %v", name, err)
+		}
+	}
+
+	settings := DefaultSettings()
+	settings.OracleHashType = "sha256"
+	if _, err := GetVkUltraHonk("", settings); !errors.Is(err, ErrInvalidSettings) {
+		t.Fatalf("expected GetVkUltraHonk to validate settings, got %v", err)
+	}
+}
+
+func TestPrepareRecursionInputs(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+	settings.IpaAccumulation = true
+
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	inputs, err := PrepareRecursionInputs(proof, vk, settings)
+	if err != nil {
+		t.Fatalf("failed to prepare recursion inputs: %v", err)
+	}
+	if inputs.NumPublicInputs != len(inputs.PublicInputs) || len(inputs.Proof) == 0 || len(inputs.VerificationKey) == 0 {
+		t.Fatalf("unexpected recursion inputs: %+v", inputs)
+	}
+	if !strings.HasPrefix(inputs.KeyHash, "0x") {
+		t.Fatalf("unexpected key hash %q", inputs.KeyHash)
+	}
+
+	// A proof produced without IPA accumulation is rejected, as is a key without an IPA claim.
+	plain, err := ProveUltraHonk(bytecode, witnessJSON, DefaultSettings())
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	if _, err := PrepareRecursionInputs(plain, vk, settings); err == nil {
+		t.Fatalf("expected error for a proof without an IPA claim")
+	}
+	plainVk, err := GetVkUltraHonk(bytecode, DefaultSettings())
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	if _, err := PrepareRecursionInputs(proof, plainVk, settings); !errors.Is(err, ErrIncompatibleSettings) {
+		t.Fatalf("expected ErrIncompatibleSettings, got %v", err)
+	}
+	if _, err := PrepareRecursionInputs(plain, vk, DefaultSettings()); err == nil {
+		t.Fatalf("expected error for settings without IPA accumulation")
+	}
+}
+
+func TestProveUltraHonkWithVk(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	proof, err := ProveUltraHonkWithVk(bytecode, witnessJSON, vk, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	if !VerifyUltraHonk(proof, vk, settings) {
+		t.Fatalf("proof with a precomputed VK failed verification")
+	}
+
+	ipaSettings := settings
+	ipaSettings.IpaAccumulation = true
+	if _, err := ProveUltraHonkWithVk(bytecode, witnessJSON, vk, ipaSettings); !errors.Is(err, ErrVkMismatch) {
+		t.Fatalf("expected ErrVkMismatch for a VK computed with other settings, got %v", err)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	version, err := Version()
+	if err != nil {
+		t.Fatalf("unexpected version error: %v", err)
+	}
+	if version == "" {
+		t.Fatalf("empty native library version")
+	}
+	if majorMinor("v0.1.7") != majorMinor(NativeVersion) || majorMinor("0.2.0") == majorMinor(NativeVersion) {
+		t.Fatalf("unexpected version compatibility for %s", NativeVersion)
+	}
+}
+
 func TestBase64(t *testing.T) {
 	s := "H4sIAAAAAAAA/4XMPQ5AMBCF4atMvYVIs9No9S6Gv0SjUInG7S080Ssq3reYDxSlRE9t"
 	_, err := base64.StdEncoding.DecodeString(s)
@@ -62,3 +306,578 @@ func TestBase64(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestProveVerifyHandle(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	h, err := ProveUltraHonkHandle(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	defer h.Free()
+
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	if !VerifyUltraHonkHandle(h, vk, settings) {
+		t.Fatalf("Verification from handle failed")
+	}
+	if !VerifyUltraHonk(h.Bytes(), vk, settings) {
+		t.Fatalf("Verification of copied proof failed")
+	}
+
+	h.Free()
+	if VerifyUltraHonkHandle(h, vk, settings) {
+		t.Fatalf("expected verification of a freed handle to fail")
+	}
+}
+
+// BenchmarkBackends compares proving throughput of the pipe and native backends.
+// Peak RSS is reported for this process and for its children, which is where the `bb`
+// subprocess of the pipe backend allocates.
+func BenchmarkBackends(b *testing.B) {
+	bytecode, witnessJSON := testCircuit(b)
+	settings := DefaultSettings()
+
+	prev, hadPrev := os.LookupEnv("BB_BACKEND_TYPE")
+	defer func() {
+		if hadPrev {
+			os.Setenv("BB_BACKEND_TYPE", prev)
+		} else {
+			os.Unsetenv("BB_BACKEND_TYPE")
+		}
+		ResetBackend()
+	}()
+
+	for _, backend := range []BackendType{BackendPipe, BackendNative} {
+		b.Run(string(backend), func(b *testing.B) {
+			SetBackendType(backend)
+
+			// The first proof starts the backend, keep it out of the measurement.
+			if _, err := ProveUltraHonk(bytecode, witnessJSON, settings); err != nil {
+				b.Skipf("backend %s unavailable: %v", backend, err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := ProveUltraHonk(bytecode, witnessJSON, settings); err != nil {
+					b.Fatalf("failed to prove: %v", err)
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "proofs/s")
+			if self, children, ok := peakRSS(); ok {
+				b.ReportMetric(float64(self)/(1<<20), "self-peak-rss-MB")
+				b.ReportMetric(float64(children)/(1<<20), "child-peak-rss-MB")
+			}
+		})
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+
+	v, err := NewVerifier(vk, settings)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		ok, err := v.Verify(proof)
+		if err != nil || !ok {
+			t.Fatalf("verification %d failed: ok=%v err=%v", i, ok, err)
+		}
+	}
+
+	p, err := decodeProof(proof)
+	if err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	p.proof[len(p.proof)-1][31] ^= 1
+	if ok, err := v.Verify(p.encode()); ok || !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("expected ErrInvalidProof for a tampered proof, got ok=%v err=%v", ok, err)
+	}
+
+	if err := v.Close(); err != nil {
+		t.Fatalf("failed to close verifier: %v", err)
+	}
+	if _, err := v.Verify(proof); !errors.Is(err, ErrVerifierClosed) {
+		t.Fatalf("expected ErrVerifierClosed, got %v", err)
+	}
+}
+
+func TestBenchmarkVerify(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+
+	rate, err := BenchmarkVerify(proof, vk, settings, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("benchmark failed: %v", err)
+	}
+	if rate <= 0 {
+		t.Fatalf("unexpected verification rate %v", rate)
+	}
+	t.Logf("%.1f verifications/s", rate)
+}
+
+func TestProveUltraHonkContext(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	proof, err := ProveUltraHonkContext(ctx, bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	if len(proof) == 0 {
+		t.Fatalf("empty proof")
+	}
+
+	cancel()
+	if _, err := ProveUltraHonkContext(ctx, bytecode, witnessJSON, settings); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestProverContext(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	ctx, err := NewProverContext(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to create prover context: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		proof, err := ctx.Prove(witnessJSON)
+		if err != nil {
+			t.Fatalf("proof %d failed: %v", i, err)
+		}
+		if !VerifyUltraHonk(proof, ctx.VK(), settings) {
+			t.Fatalf("proof %d failed verification", i)
+		}
+	}
+
+	if err := ctx.Close(); err != nil {
+		t.Fatalf("failed to close prover context: %v", err)
+	}
+	if _, err := ctx.Prove(witnessJSON); !errors.Is(err, ErrProverClosed) {
+		t.Fatalf("expected ErrProverClosed, got %v", err)
+	}
+}
+
+func TestProveBatch(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	ctx, err := NewProverContext(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to create prover context: %v", err)
+	}
+	defer ctx.Close()
+
+	witnesses := []string{witnessJSON, `{"witness": ["0xzz"]}`, witnessJSON}
+	proofs, errs := ctx.ProveBatch(witnesses, 2)
+	if len(proofs) != len(witnesses) || len(errs) != len(witnesses) {
+		t.Fatalf("unexpected result sizes %d, %d", len(proofs), len(errs))
+	}
+	for _, i := range []int{0, 2} {
+		if errs[i] != nil {
+			t.Fatalf("proof %d failed: %v", i, errs[i])
+		}
+		if !VerifyUltraHonk(proofs[i], ctx.VK(), settings) {
+			t.Fatalf("proof %d failed verification", i)
+		}
+	}
+	if errs[1] == nil {
+		t.Fatalf("expected error for a malformed witness")
+	}
+}
+
+func TestCircuitRegistry(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	r := NewCircuitRegistry()
+	defer r.Close()
+
+	if err := r.Register("square", bytecode, DefaultSettings()); err != nil {
+		t.Fatalf("failed to register circuit: %v", err)
+	}
+	if err := r.Register("square", bytecode, DefaultSettings()); err == nil {
+		t.Fatalf("expected error registering a circuit twice")
+	}
+
+	proof, err := r.Prove("square", witnessJSON)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	if ok, err := r.Verify("square", proof); err != nil || !ok {
+		t.Fatalf("verification failed: ok=%v err=%v", ok, err)
+	}
+	if _, err := r.Verify("missing", proof); err == nil {
+		t.Fatalf("expected error for an unregistered circuit")
+	}
+}
+
+func TestAssertBoundToInputs(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+
+	// y = 9 is the only public input.
+	var y [32]byte
+	y[31] = 9
+	if err := AssertBoundToInputs(proof, vk, [][32]byte{y}, settings); err != nil {
+		t.Fatalf("proof not bound to its inputs: %v", err)
+	}
+	y[31] = 10
+	if err := AssertBoundToInputs(proof, vk, [][32]byte{y}, settings); err == nil {
+		t.Fatalf("expected error for a different public input")
+	}
+	if err := AssertBoundToInputs(proof, vk, nil, settings); err == nil {
+		t.Fatalf("expected error for a missing public input")
+	}
+
+	// A proof bound to its inputs but checked with the wrong settings reports the mismatch.
+	y[31] = 9
+	ipa := settings
+	ipa.IpaAccumulation = true
+	err = AssertBoundToInputs(proof, vk, [][32]byte{y}, ipa)
+	if !errors.Is(err, ErrVerificationFailed) || !errors.Is(err, ErrVkMismatch) {
+		t.Fatalf("expected ErrVerificationFailed wrapping ErrVkMismatch, got %v", err)
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	if err := Warmup(DefaultSettings()); err != nil {
+		t.Fatalf("warmup failed: %v", err)
+	}
+}
+
+func TestWriteSolidityVerifier(t *testing.T) {
+	bytecode, _ := testCircuit(t)
+	settings := DefaultSettings()
+	settings.OracleHashType = HashKeccak
+
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	code, err := WriteSolidityVerifier(vk, settings)
+	if err != nil {
+		t.Fatalf("failed to write solidity verifier: %v", err)
+	}
+	if !strings.Contains(code, "pragma solidity") {
+		t.Fatalf("unexpected solidity verifier: %.100q", code)
+	}
+
+	if _, err := WriteSolidityVerifier(vk, DefaultSettings()); err == nil {
+		t.Fatalf("expected error for the poseidon2 oracle hash")
+	}
+}
+
+// TestSolidityVKHash checks the Keccak VK hash against the one bb embeds in its Solidity verifier.
+func TestSolidityVKHash(t *testing.T) {
+	bytecode, _ := testCircuit(t)
+	settings := DefaultSettings()
+	settings.OracleHashType = HashKeccak
+
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	code, err := WriteSolidityVerifier(vk, settings)
+	if err != nil {
+		t.Fatalf("failed to write solidity verifier: %v", err)
+	}
+	m := regexp.MustCompile(`VK_HASH = (0x[0-9a-fA-F]+)`).FindStringSubmatch(code)
+	if m == nil {
+		t.Fatalf("solidity verifier does not embed a VK hash")
+	}
+	want, ok := new(big.Int).SetString(m[1][2:], 16)
+	if !ok {
+		t.Fatalf("invalid embedded VK hash %q", m[1])
+	}
+
+	// VKHashType gives the same Keccak hash when it overrides a Poseidon2 oracle.
+	for _, s := range []ProofSystemSettings{settings, {OracleHashType: HashPoseidon2, VKHashType: HashKeccak}} {
+		h, err := SolidityVKHash(vk, s)
+		if err != nil {
+			t.Fatalf("failed to hash VK: %v", err)
+		}
+		if new(big.Int).SetBytes(h[:]).Cmp(want) != 0 {
+			t.Fatalf("VK hash %s does not match the embedded %s", h, m[1])
+		}
+	}
+
+	settings.VKHashType = HashPoseidon2
+	if _, err := WriteSolidityVerifier(vk, settings); err == nil {
+		t.Fatalf("expected error for a VK hash the verifier can't embed")
+	}
+}
+
+// TestProofToEvmCalldataVerifier checks the calldata against the sizes the generated verifier
+// expects, which counts the pairing points among its public inputs.
+func TestProofToEvmCalldataVerifier(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+	settings.OracleHashType = HashKeccak
+
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	code, err := WriteSolidityVerifier(vk, settings)
+	if err != nil {
+		t.Fatalf("failed to write solidity verifier: %v", err)
+	}
+	proofBytes, publicInputs, err := ProofToEvmCalldata(proof, settings)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+
+	m := regexp.MustCompile(`NUMBER_OF_PUBLIC_INPUTS = (\d+)`).FindStringSubmatch(code)
+	if m == nil {
+		t.Fatalf("solidity verifier does not declare its number of public inputs")
+	}
+	if want := strconv.Itoa(len(publicInputs) + pairingPointsSize); m[1] != want {
+		t.Fatalf("verifier expects %s public inputs, calldata has %s including the pairing points", m[1], want)
+	}
+	if len(proofBytes)%32 != 0 || len(proofBytes) < pairingPointsSize*32 {
+		t.Fatalf("unexpected proof calldata size %d", len(proofBytes))
+	}
+}
+
+func TestVkAsFields(t *testing.T) {
+	bytecode, _ := testCircuit(t)
+	vk, err := GetVkUltraHonk(bytecode, DefaultSettings())
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	fields, err := VkAsFields(vk)
+	if err != nil {
+		t.Fatalf("failed to convert VK: %v", err)
+	}
+	// The header words come first, starting with the log circuit size.
+	var logCircuitSize Fr
+	copy(logCircuitSize[:], vk[:fieldSize])
+	if len(fields) < 3 || fields[0] != logCircuitSize.String() {
+		t.Fatalf("unexpected VK fields: %v", fields)
+	}
+}
+
+func TestBackendErrorCodes(t *testing.T) {
+	bytecode, _ := testCircuit(t)
+	settings := DefaultSettings()
+
+	if _, err := ProveUltraHonk("not bytecode", `{"witness": ["0x03", "0x09"]}`, settings); !errors.Is(err, ErrInvalidBytecode) {
+		t.Fatalf("expected ErrInvalidBytecode, got %v", err)
+	}
+	if _, err := ProveUltraHonk(bytecode, `{"witness": ["0xzz"]}`, settings); !errors.Is(err, ErrInvalidWitness) {
+		t.Fatalf("expected ErrInvalidWitness, got %v", err)
+	}
+}
+
+func TestCircuitInfo(t *testing.T) {
+	bytecode, _ := testCircuit(t)
+	stats, err := CircuitInfo(bytecode, DefaultSettings())
+	if err != nil {
+		t.Fatalf("failed to get circuit info: %v", err)
+	}
+	if stats.PublicInputCount != 1 || stats.NumAcirOpcodes == 0 || stats.GateCount == 0 {
+		t.Fatalf("unexpected circuit info: %+v", stats)
+	}
+	if stats.SubgroupSize < stats.GateCount || stats.SubgroupSize&(stats.SubgroupSize-1) != 0 {
+		t.Fatalf("subgroup size %d is not a power of two covering %d gates", stats.SubgroupSize, stats.GateCount)
+	}
+}
+
+func TestExecuteWitness(t *testing.T) {
+	bytecode, _ := testCircuit(t)
+	artifact, err := os.ReadFile("testdata/circuit/target/circuit.json")
+	if err != nil {
+		t.Fatalf("failed to read circuit.json: %v", err)
+	}
+
+	witnessJSON, err := ExecuteWitness(string(artifact), `{"x": 3, "y": 9}`)
+	if err != nil {
+		t.Fatalf("failed to execute circuit: %v", err)
+	}
+	settings := DefaultSettings()
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove with the solved witness: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	if !VerifyUltraHonk(proof, vk, settings) {
+		t.Fatalf("proof from the solved witness failed verification")
+	}
+
+	if _, err := ExecuteWitness(string(artifact), `{"x": 3, "y": 10}`); !errors.Is(err, ErrUnsatisfiedConstraint) {
+		t.Fatalf("expected ErrUnsatisfiedConstraint, got %v", err)
+	}
+	if _, err := ExecuteWitness(string(artifact), `{"x": 3}`); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestClientIVCArguments(t *testing.T) {
+	// A valid accumulation needs kernel circuits, so only argument checks are tested here.
+	if _, err := ProveClientIVC(nil, nil); err == nil {
+		t.Fatalf("expected error for no circuits")
+	}
+	if _, err := ProveClientIVC([]string{"a", "b"}, []string{"{}"}); err == nil {
+		t.Fatalf("expected error for mismatched witnesses")
+	}
+	if _, err := GetVkClientIVC(nil); err == nil {
+		t.Fatalf("expected error for no circuits")
+	}
+	if VerifyClientIVC(nil, []byte{1}) {
+		t.Fatalf("empty proof verified")
+	}
+}
+
+func TestPublicInputIndices(t *testing.T) {
+	bytecode, _ := testCircuit(t)
+	indices, err := PublicInputIndices(bytecode)
+	if err != nil {
+		t.Fatalf("failed to get public input indices: %v", err)
+	}
+	// main(x: Field, y: pub Field): y is the only public input.
+	if len(indices) != 1 || indices[0] != 1 {
+		t.Fatalf("unexpected public input indices: %v", indices)
+	}
+}
+
+func TestVerifyAndDecode(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	artifact, err := os.ReadFile("testdata/circuit/target/circuit.json")
+	if err != nil {
+		t.Fatalf("failed to read circuit.json: %v", err)
+	}
+	settings := DefaultSettings()
+
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	decoded, err := VerifyAndDecode(proof, string(artifact), settings)
+	if err != nil {
+		t.Fatalf("failed to verify and decode: %v", err)
+	}
+	y := decoded.PublicInputs["y"]
+	if len(y) != 1 || y[0][31] != 9 || decoded.ReturnValue != nil || decoded.ReturnType != "" {
+		t.Fatalf("unexpected decoded proof: %+v", decoded)
+	}
+
+	// A rejected proof reports the backend's reason along with ErrVerificationFailed.
+	p, err := decodeProof(proof)
+	if err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	p.proof[len(p.proof)-1][31] ^= 1
+	_, err = VerifyAndDecode(p.encode(), string(artifact), settings)
+	var be *BackendError
+	if !errors.Is(err, ErrVerificationFailed) || !errors.As(err, &be) {
+		t.Fatalf("expected ErrVerificationFailed wrapping a *BackendError, got %v", err)
+	}
+}
+
+func TestProveUltraHonkLazyWitness(t *testing.T) {
+	bytecode, _ := testCircuit(t)
+	settings := DefaultSettings()
+
+	// Pass one value per chunk, so the witness is streamed across several calls.
+	defer func(n int) { witnessChunkValues = n }(witnessChunkValues)
+	witnessChunkValues = 1
+
+	values := []byte{3, 9}
+	proof, err := ProveUltraHonkLazyWitness(bytecode, func(i int) ([32]byte, bool) {
+		var v [32]byte
+		if i >= len(values) {
+			return v, false
+		}
+		v[31] = values[i]
+		return v, true
+	}, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	if !VerifyUltraHonk(proof, vk, settings) {
+		t.Fatalf("Verification failed")
+	}
+}
+
+func TestVerifyUltraHonkReceipt(t *testing.T) {
+	bytecode, witnessJSON := testCircuit(t)
+	settings := DefaultSettings()
+
+	proof, err := ProveUltraHonk(bytecode, witnessJSON, settings)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	vk, err := GetVkUltraHonk(bytecode, settings)
+	if err != nil {
+		t.Fatalf("failed to get VK: %v", err)
+	}
+	r, err := VerifyUltraHonkReceipt(proof, vk, settings)
+	if err != nil {
+		t.Fatalf("failed to verify: %v", err)
+	}
+	if !r.Verified || r.Error != "" || r.ErrorCode != "" {
+		t.Fatalf("unexpected receipt for a valid proof: %+v", r)
+	}
+
+	p, err := decodeProof(proof)
+	if err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	p.proof[len(p.proof)-1][31] ^= 1
+	r, err = VerifyUltraHonkReceipt(p.encode(), vk, settings)
+	if err != nil {
+		t.Fatalf("failed to verify: %v", err)
+	}
+	if r.Verified || r.Error == "" || r.ErrorCode == "" {
+		t.Fatalf("receipt of a rejected proof does not record why: %+v", r)
+	}
+}
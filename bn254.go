@@ -0,0 +1,61 @@
+package barretenberg
+
+import (
+	"encoding/hex"
+	"math/big"
+)
+
+// frModulus is the order of the bn254 scalar field, in which circuit values live.
+var frModulus, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
+// Fr is a bn254 scalar field element, serialized as 32 bytes big-endian.
+type Fr [32]byte
+
+// String returns the element as a 0x-prefixed hex string.
+func (f Fr) String() string {
+	return "0x" + hex.EncodeToString(f[:])
+}
+
+// fqModulus is the order of the bn254 base field, in which curve point coordinates live.
+var fqModulus, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+
+// g1PointSize is the size of an uncompressed G1 point: x then y, 32 bytes big-endian each.
+const g1PointSize = 64
+
+// isG1Generator reports whether the uncompressed G1 point p is the generator (1, 2), the first
+// point of every SRS since it is tau^0 * G.
+func isG1Generator(p []byte) bool {
+	var generator [g1PointSize]byte
+	generator[fieldSize-1], generator[g1PointSize-1] = 1, 2
+	return string(p) == string(generator[:])
+}
+
+// isOnCurveG1 reports whether (x, y) is a point of the bn254 G1 curve y^2 = x^3 + 3,
+// with both coordinates reduced. bn254 G1 has cofactor 1, so this also implies subgroup membership.
+func isOnCurveG1(x, y *big.Int) bool {
+	if x.Cmp(fqModulus) >= 0 || y.Cmp(fqModulus) >= 0 {
+		return false
+	}
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, fqModulus)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, big.NewInt(3))
+	rhs.Mod(rhs, fqModulus)
+	return lhs.Cmp(rhs) == 0
+}
+
+// isOnCurveGrumpkin reports whether (x, y) is a point of the Grumpkin curve y^2 = x^3 - 17,
+// whose base field is the bn254 scalar field. The IPA commitments of rollup proofs live on it.
+func isOnCurveGrumpkin(x, y *big.Int) bool {
+	if x.Cmp(frModulus) >= 0 || y.Cmp(frModulus) >= 0 {
+		return false
+	}
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, frModulus)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x)
+	rhs.Sub(rhs, big.NewInt(17))
+	rhs.Mod(rhs, frModulus)
+	return lhs.Cmp(rhs) == 0
+}
@@ -0,0 +1,82 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// Client IVC (ClientIVC, or Chonk in Barretenberg's API) folds a sequence of circuits with
+// MegaHonk and produces a single composed proof, including the ECCVM and translator proofs
+// that the backend builds internally. Its settings are fixed by the proof system, so these
+// functions take no ProofSystemSettings. The circuits must form a valid accumulation: kernel
+// circuits have to recursively verify the previous steps, as the Aztec protocol circuits do.
+
+// cStringArray copies strs into a C array of C strings. free releases the array and its strings.
+func cStringArray(strs []string) (array **C.char, free func()) {
+	ptrs := unsafe.Slice((**C.char)(C.malloc(C.size_t(len(strs))*C.size_t(unsafe.Sizeof((*C.char)(nil))))), len(strs))
+	for i, s := range strs {
+		ptrs[i] = C.CString(s)
+	}
+	return &ptrs[0], func() {
+		for _, p := range ptrs {
+			C.free(unsafe.Pointer(p))
+		}
+		C.free(unsafe.Pointer(&ptrs[0]))
+	}
+}
+
+// ProveClientIVC accumulates the circuits in order, each with its witness JSON in the format
+// accepted by ProveUltraHonk, and returns the composed Client IVC proof.
+func ProveClientIVC(bytecodes []string, witnessJsons []string) ([]byte, error) {
+	if len(bytecodes) == 0 {
+		return nil, errors.New("no circuits to accumulate")
+	}
+	if len(bytecodes) != len(witnessJsons) {
+		return nil, fmt.Errorf("got %d circuits and %d witnesses", len(bytecodes), len(witnessJsons))
+	}
+	cBytecodes, freeBytecodes := cStringArray(bytecodes)
+	defer freeBytecodes()
+	cWitnesses, freeWitnesses := cStringArray(witnessJsons)
+	defer freeWitnesses()
+
+	backendMu.RLock()
+	r := C.bb_prove_client_ivc(cBytecodes, cWitnesses, C.uintptr_t(len(bytecodes)))
+	backendMu.RUnlock()
+	return resultToBytes(r)
+}
+
+// GetVkClientIVC returns the verification key of Client IVC proofs of the given circuits.
+// The key only depends on the last circuit of the accumulation, which verifies all previous steps.
+func GetVkClientIVC(bytecodes []string) ([]byte, error) {
+	if len(bytecodes) == 0 {
+		return nil, errors.New("no circuits to accumulate")
+	}
+	cBytecode := C.CString(bytecodes[len(bytecodes)-1])
+	defer C.free(unsafe.Pointer(cBytecode))
+
+	backendMu.RLock()
+	r := C.bb_get_vk_client_ivc(cBytecode)
+	backendMu.RUnlock()
+	return resultToBytes(r)
+}
+
+// VerifyClientIVC verifies a Client IVC proof using its verification key.
+func VerifyClientIVC(proof []byte, vk []byte) bool {
+	if len(proof) == 0 || len(vk) == 0 {
+		return false
+	}
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return bool(C.bb_verify_client_ivc(
+		(*C.uint8_t)(unsafe.Pointer(&proof[0])),
+		C.uintptr_t(len(proof)),
+		(*C.uint8_t)(unsafe.Pointer(&vk[0])),
+		C.uintptr_t(len(vk)),
+	))
+}
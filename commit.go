@@ -0,0 +1,52 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// CommitWitness computes a Pedersen commitment to the witness values at the given indices,
+// in the order given, matching Noir's std::hash::pedersen_commitment with the default
+// generator index. The commitment is a Grumpkin point, whose coordinates are bn254 scalar
+// field elements, serialized as the 32-byte big-endian x coordinate followed by y.
+// witnessJson uses the same format as ProveUltraHonk.
+func CommitWitness(witnessJson string, indices []int) ([64]byte, error) {
+	var commitment [64]byte
+
+	values, err := parseWitness(witnessJson)
+	if err != nil {
+		return commitment, err
+	}
+	if len(indices) == 0 {
+		return commitment, fmt.Errorf("no witness indices to commit to")
+	}
+	inputs := make([]byte, 0, len(indices)*fieldSize)
+	for _, i := range indices {
+		if i < 0 || i >= len(values) {
+			return commitment, fmt.Errorf("witness index %d out of range [0, %d)", i, len(values))
+		}
+		inputs = append(inputs, values[i][:]...)
+	}
+
+	backendMu.RLock()
+	r := C.bb_pedersen_commit(
+		(*C.uint8_t)(unsafe.Pointer(&inputs[0])),
+		C.uintptr_t(len(indices)),
+		0,
+	)
+	backendMu.RUnlock()
+	point, err := resultToBytes(r)
+	if err != nil {
+		return commitment, err
+	}
+	if len(point) != len(commitment) {
+		return commitment, fmt.Errorf("unexpected commitment size %d from backend", len(point))
+	}
+	copy(commitment[:], point)
+	return commitment, nil
+}
@@ -0,0 +1,79 @@
+package barretenberg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIncompatibleSettings is wrapped by the errors of CheckVerifyCompatibility.
+var ErrIncompatibleSettings = errors.New("incompatible proof, verification key and settings")
+
+// CheckVerifyCompatibility returns a descriptive error if proof, vk and settings can't belong
+// together, instead of letting verification silently return false. It checks that:
+//   - the settings use a known oracle hash;
+//   - the proof and key are well-formed;
+//   - the proof carries the number of public inputs the key expects;
+//   - the key's IPA claim, or its absence, matches settings.IpaAccumulation.
+//
+// The oracle hash and ZK mode used for proving are not recorded in the proof or key bytes, so a
+// mismatch on those can't be told apart from an invalid proof.
+// Errors caused by a mismatch wrap ErrIncompatibleSettings.
+func CheckVerifyCompatibility(proof []byte, vk []byte, settings ProofSystemSettings) error {
+	switch settings.OracleHashType {
+	case HashPoseidon2, HashKeccak, HashBlake2s:
+	default:
+		return fmt.Errorf("%w: unknown oracle hash %q", ErrIncompatibleSettings, settings.OracleHashType)
+	}
+	p, err := decodeProof(proof)
+	if err != nil {
+		return err
+	}
+	h, err := parseVKHeader(vk)
+	if err != nil {
+		return err
+	}
+
+	backendInputs := uint64(pairingPointsSize)
+	if settings.IpaAccumulation {
+		backendInputs += ipaClaimSize
+	}
+	circuitInputs := h.numPublicInputs - backendInputs
+	switch {
+	case h.numPublicInputs == uint64(len(p.publicInputs))+pairingPointsSize+ipaClaimSize && !settings.IpaAccumulation:
+		return fmt.Errorf("%w: verification key expects an IPA claim but settings.IpaAccumulation is false", ErrIncompatibleSettings)
+	case h.numPublicInputs == uint64(len(p.publicInputs))+pairingPointsSize && settings.IpaAccumulation:
+		return fmt.Errorf("%w: settings.IpaAccumulation is true but the verification key has no IPA claim", ErrIncompatibleSettings)
+	case h.numPublicInputs < backendInputs || circuitInputs != uint64(len(p.publicInputs)):
+		return fmt.Errorf("%w: proof has %d public inputs, verification key expects %d", ErrIncompatibleSettings, len(p.publicInputs), int64(h.numPublicInputs)-int64(backendInputs))
+	}
+	if len(p.proof) < int(backendInputs) {
+		return fmt.Errorf("%w: proof has %d elements, too short for the public inputs added by the backend", ErrIncompatibleSettings, len(p.proof))
+	}
+	return nil
+}
+
+// AssertBoundToInputs verifies proof against vk and checks that the public inputs embedded in
+// the proof are exactly publicInputs, in the same order. Verification only shows that the proof
+// is valid for the inputs it carries, so callers that take the inputs from elsewhere, e.g. a
+// request, must check that they match to prevent substituting a proof of other inputs.
+// The inputs are compared before verifying, so a mismatch is reported without running the
+// verifier. A proof that does not verify yields an error wrapping ErrVerificationFailed and the
+// error of VerifyUltraHonkErr, e.g. ErrVkMismatch.
+func AssertBoundToInputs(proof []byte, vk []byte, publicInputs [][32]byte, settings ProofSystemSettings) error {
+	p, err := decodeProof(proof)
+	if err != nil {
+		return err
+	}
+	if len(p.publicInputs) != len(publicInputs) {
+		return fmt.Errorf("proof has %d public inputs, expected %d", len(p.publicInputs), len(publicInputs))
+	}
+	for i := range publicInputs {
+		if p.publicInputs[i] != publicInputs[i] {
+			return fmt.Errorf("public input %d of the proof is %s, expected %s", i, Fr(p.publicInputs[i]), Fr(publicInputs[i]))
+		}
+	}
+	if err := VerifyUltraHonkErr(proof, vk, settings); err != nil {
+		return verificationError(err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package barretenberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckVerifyCompatibility(t *testing.T) {
+	p := testProof([][32]byte{testField(9)}, true)
+	proof := p.encode()
+	vk := testVK(5, 1+pairingPointsSize)
+	ipaVK := testVK(5, 1+pairingPointsSize+ipaClaimSize)
+	settings := DefaultSettings()
+	ipaSettings := settings
+	ipaSettings.IpaAccumulation = true
+
+	if err := CheckVerifyCompatibility(proof, vk, settings); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := CheckVerifyCompatibility(proof, ipaVK, ipaSettings); err != nil {
+		t.Fatalf("unexpected error with IPA accumulation: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		vk       []byte
+		settings ProofSystemSettings
+	}{
+		"missing IPA setting": {ipaVK, settings},
+		"unexpected IPA":      {vk, ipaSettings},
+		"public inputs":       {testVK(5, 3+pairingPointsSize), settings},
+		"oracle hash":         {vk, ProofSystemSettings{OracleHashType: "sha256"}},
+	} {
+		if err := CheckVerifyCompatibility(proof, tc.vk, tc.settings); !errors.Is(err, ErrIncompatibleSettings) {
+			t.Errorf("%s: expected ErrIncompatibleSettings, got %v", name, err)
+		}
+	}
+}
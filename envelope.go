@@ -0,0 +1,112 @@
+package barretenberg
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// envelopeVersion is the version of the JSON envelope written by MarshalProof and MarshalVK.
+const envelopeVersion = 1
+
+// envelope is the JSON form of a proof or verification key, recording the settings it was
+// produced with so it can't later be verified with the wrong ones.
+type envelope struct {
+	Version   int            `json:"version"`
+	Scheme    string         `json:"scheme"`
+	Oracle    OracleHashType `json:"oracle"`
+	DisableZk bool           `json:"disable_zk"`
+	Ipa       bool           `json:"ipa"`
+	Data      string         `json:"data"`
+}
+
+func marshalEnvelope(data []byte, settings ProofSystemSettings) ([]byte, error) {
+	return json.Marshal(envelope{
+		Version:   envelopeVersion,
+		Scheme:    "ultrahonk",
+		Oracle:    settings.OracleHashType,
+		DisableZk: settings.DisableZk,
+		Ipa:       settings.IpaAccumulation,
+		Data:      "0x" + hex.EncodeToString(data),
+	})
+}
+
+func unmarshalEnvelope(b []byte) ([]byte, ProofSystemSettings, error) {
+	var e envelope
+	var settings ProofSystemSettings
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, settings, fmt.Errorf("invalid envelope: %w", err)
+	}
+	if e.Version != envelopeVersion {
+		return nil, settings, fmt.Errorf("unsupported envelope version %d", e.Version)
+	}
+	if e.Scheme != "ultrahonk" {
+		return nil, settings, fmt.Errorf("unsupported proof scheme %q", e.Scheme)
+	}
+	if !strings.HasPrefix(e.Data, "0x") {
+		return nil, settings, errors.New("invalid envelope: data must be 0x-prefixed hex")
+	}
+	data, err := hex.DecodeString(e.Data[2:])
+	if err != nil {
+		return nil, settings, fmt.Errorf("invalid envelope data: %w", err)
+	}
+	settings = DefaultSettings()
+	settings.OracleHashType = e.Oracle
+	settings.DisableZk = e.DisableZk
+	settings.IpaAccumulation = e.Ipa
+	if err := settings.Validate(); err != nil {
+		return nil, ProofSystemSettings{}, err
+	}
+	return data, settings, nil
+}
+
+// MarshalProof wraps a proof returned by ProveUltraHonk in a JSON envelope, for storing it or
+// sending it over JSON APIs:
+//
+//	{"version":1,"scheme":"ultrahonk","oracle":"keccak","disable_zk":false,"ipa":false,"data":"0x..."}
+//
+// settings must be the ones the proof was produced with. The oracle hash, ZK and IPA flags are
+// recorded so UnmarshalProof can return the settings needed to verify it.
+func MarshalProof(proof []byte, settings ProofSystemSettings) ([]byte, error) {
+	if _, err := decodeProof(proof); err != nil {
+		return nil, err
+	}
+	return marshalEnvelope(proof, settings)
+}
+
+// UnmarshalProof returns the proof held by an envelope written by MarshalProof, byte for byte,
+// together with the settings to verify it with. Envelopes of an unknown version are rejected.
+func UnmarshalProof(b []byte) ([]byte, ProofSystemSettings, error) {
+	proof, settings, err := unmarshalEnvelope(b)
+	if err != nil {
+		return nil, settings, err
+	}
+	if _, err := decodeProof(proof); err != nil {
+		return nil, settings, err
+	}
+	return proof, settings, nil
+}
+
+// MarshalVK wraps a verification key returned by GetVkUltraHonk in a JSON envelope, in the
+// same format as MarshalProof.
+func MarshalVK(vk []byte, settings ProofSystemSettings) ([]byte, error) {
+	if _, err := parseVKHeader(vk); err != nil {
+		return nil, err
+	}
+	return marshalEnvelope(vk, settings)
+}
+
+// UnmarshalVK returns the verification key held by an envelope written by MarshalVK, together
+// with the settings it was generated with.
+func UnmarshalVK(b []byte) ([]byte, ProofSystemSettings, error) {
+	vk, settings, err := unmarshalEnvelope(b)
+	if err != nil {
+		return nil, settings, err
+	}
+	if _, err := parseVKHeader(vk); err != nil {
+		return nil, settings, err
+	}
+	return vk, settings, nil
+}
@@ -0,0 +1,64 @@
+package barretenberg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProofEnvelope(t *testing.T) {
+	p := &proofResponse{
+		publicInputs: [][32]byte{testField(9)},
+		proof:        [][32]byte{testField(1), testField(0xff)},
+	}
+	proof := p.encode()
+	settings := DefaultSettings()
+	settings.OracleHashType = HashKeccak
+	settings.DisableZk = true
+
+	b, err := MarshalProof(proof, settings)
+	if err != nil {
+		t.Fatalf("failed to marshal proof: %v", err)
+	}
+	got, gotSettings, err := UnmarshalProof(b)
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	if !bytes.Equal(got, proof) {
+		t.Fatalf("proof did not round-trip")
+	}
+	if gotSettings.OracleHashType != HashKeccak || !gotSettings.DisableZk || gotSettings.IpaAccumulation {
+		t.Fatalf("unexpected settings: %+v", gotSettings)
+	}
+
+	for _, bad := range []string{
+		strings.Replace(string(b), `"version":1`, `"version":2`, 1),
+		strings.Replace(string(b), `"keccak"`, `"sha256"`, 1),
+		strings.Replace(string(b), `"data":"0x`, `"data":"`, 1),
+	} {
+		if _, _, err := UnmarshalProof([]byte(bad)); err == nil {
+			t.Errorf("expected error for envelope %s", bad)
+		}
+	}
+	if _, err := MarshalProof(proof[:10], settings); err == nil {
+		t.Fatalf("expected error marshaling a truncated proof")
+	}
+}
+
+func TestVKEnvelope(t *testing.T) {
+	vk := testVK(5, pairingPointsSize)
+	b, err := MarshalVK(vk, DefaultSettings())
+	if err != nil {
+		t.Fatalf("failed to marshal VK: %v", err)
+	}
+	got, settings, err := UnmarshalVK(b)
+	if err != nil {
+		t.Fatalf("failed to unmarshal VK: %v", err)
+	}
+	if !bytes.Equal(got, vk) || settings != DefaultSettings() {
+		t.Fatalf("VK did not round-trip: settings %+v", settings)
+	}
+	if _, _, err := UnmarshalProof(b); err == nil {
+		t.Fatalf("expected error unmarshaling a VK envelope as a proof")
+	}
+}
@@ -0,0 +1,78 @@
+package barretenberg
+
+import "strings"
+
+// ErrorCode classifies the errors reported by the backend. An ErrorCode is itself an error,
+// so callers can test for a code with errors.Is, e.g. errors.Is(err, ErrInvalidWitness).
+type ErrorCode int
+
+const (
+	ErrUnknown               ErrorCode = iota // the backend failed for a reason not classified below
+	ErrSRSNotInitialized                      // the SRS could not be loaded
+	ErrInvalidWitness                         // the witness is malformed
+	ErrInvalidBytecode                        // the bytecode is not a valid base64, gzipped ACIR program
+	ErrUnsatisfiedConstraint                  // the circuit's constraints don't hold for the given inputs
+	ErrInvalidInput                           // circuit inputs don't match the ABI
+	ErrSRSTooSmall                            // the loaded SRS has fewer points than the circuit needs
+	ErrInvalidProof                           // the proof is malformed or does not verify
+	ErrVkMismatch                             // the proof does not match the verification key and settings
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrUnknown:               "unknown backend error",
+	ErrSRSNotInitialized:     "SRS not initialized",
+	ErrInvalidWitness:        "invalid witness",
+	ErrInvalidBytecode:       "invalid bytecode",
+	ErrUnsatisfiedConstraint: "unsatisfied constraint",
+	ErrInvalidInput:          "invalid input",
+	ErrSRSTooSmall:           "SRS too small",
+	ErrInvalidProof:          "invalid proof",
+	ErrVkMismatch:            "verification key mismatch",
+}
+
+func (c ErrorCode) Error() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	return "unknown backend error"
+}
+
+// nativeErrorPrefixes maps the prefixes of the native error messages to their codes.
+var nativeErrorPrefixes = []struct {
+	prefix string
+	code   ErrorCode
+}{
+	{"srs not initialized: ", ErrSRSNotInitialized},
+	{"invalid witness: ", ErrInvalidWitness},
+	{"invalid bytecode: ", ErrInvalidBytecode},
+	{"unsatisfied constraint: ", ErrUnsatisfiedConstraint},
+	{"srs too small: ", ErrSRSTooSmall},
+	{"invalid proof: ", ErrInvalidProof},
+}
+
+// BackendError is the error returned when the backend fails or rejects its inputs.
+// Use errors.As to access it.
+type BackendError struct {
+	Code    ErrorCode
+	Message string // message of the backend, unchanged
+	Stderr  string // last stderr lines of the `bb` subprocess for the failed call, with BackendPipe
+}
+
+func (e *BackendError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error code, so errors.Is(err, code) reports whether err has that code.
+func (e *BackendError) Unwrap() error {
+	return e.Code
+}
+
+// newBackendError classifies a native error message.
+func newBackendError(msg string) *BackendError {
+	for _, p := range nativeErrorPrefixes {
+		if strings.HasPrefix(msg, p.prefix) {
+			return &BackendError{Code: p.code, Message: msg}
+		}
+	}
+	return &BackendError{Code: ErrUnknown, Message: msg}
+}
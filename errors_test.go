@@ -0,0 +1,35 @@
+package barretenberg
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestBackendError(t *testing.T) {
+	msg := "invalid witness: value 1: Hex string too long for field element"
+	err := fmt.Errorf("prove: %w", newBackendError(msg))
+
+	var be *BackendError
+	if !errors.As(err, &be) {
+		t.Fatalf("expected a *BackendError, got %T", err)
+	}
+	if be.Code != ErrInvalidWitness || be.Message != msg {
+		t.Fatalf("unexpected backend error: %+v", be)
+	}
+	if !errors.Is(err, ErrInvalidWitness) || errors.Is(err, ErrInvalidBytecode) {
+		t.Fatalf("errors.Is does not match the error code")
+	}
+
+	if code := newBackendError("Unexpected response").Code; code != ErrUnknown {
+		t.Fatalf("expected ErrUnknown for an unclassified message, got %v", code)
+	}
+}
+
+func TestBackendErrorStderr(t *testing.T) {
+	e := newBackendError("invalid witness: bad value")
+	e.Stderr = "bb: failed to parse witness"
+	if e.Error() != "invalid witness: bad value" || !errors.Is(e, ErrInvalidWitness) {
+		t.Fatalf("stderr must not change the backend message: %q", e.Error())
+	}
+}
@@ -0,0 +1,58 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+)
+
+// ExecuteWitness solves the circuit's witness from named inputs, such as `{"x": 3, "y": 9}`,
+// and returns it as the witness JSON accepted by ProveUltraHonk. It runs the ACVM over the
+// bytecode as `nargo execute` does, with the same input encoding as Prover.toml values.
+// artifact is the program artifact JSON written by `nargo compile`, since the parameter names
+// and types are only in its ABI. Inputs are given for all parameters, public or private.
+// Errors wrap ErrInvalidInput if the inputs don't match the ABI, and ErrUnsatisfiedConstraint
+// if the circuit fails for them, e.g. on a failing assert. Programs calling other ACIR
+// functions are not supported.
+func ExecuteWitness(artifact string, inputsJson string) (string, error) {
+	a, err := parseArtifact(artifact)
+	if err != nil {
+		return "", err
+	}
+	inputs, err := a.ABI.encodeInputs(inputsJson)
+	if err != nil {
+		return "", err
+	}
+
+	cBytecode := C.CString(a.Bytecode)
+	defer C.free(unsafe.Pointer(cBytecode))
+
+	var inputsPtr *C.uint8_t
+	if len(inputs) > 0 {
+		inputsPtr = (*C.uint8_t)(unsafe.Pointer(&inputs[0][0]))
+	}
+	r := C.bb_execute_witness(cBytecode, inputsPtr, C.uintptr_t(len(inputs)))
+	data, err := resultToBytes(r)
+	if err != nil {
+		return "", err
+	}
+	values, err := splitFields(data)
+	if err != nil {
+		return "", fmt.Errorf("unexpected witness from backend: %w", err)
+	}
+
+	w := witnessFile{Witness: make([]string, len(values))}
+	for i := range values {
+		w.Witness[i] = Fr(values[i]).String()
+	}
+	out, err := json.Marshal(w)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,69 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// ProofHandle is a proof kept in native memory, so it can be verified in the same process
+// without copying it to Go and back. It must be released with Free.
+// A ProofHandle is not safe for concurrent use.
+type ProofHandle struct {
+	buf C.ByteBuffer
+}
+
+// ProveUltraHonkHandle is like ProveUltraHonk but returns a handle to the native proof buffer
+// instead of a Go copy.
+func ProveUltraHonkHandle(bytecode string, witnessJson string, settings ProofSystemSettings) (*ProofHandle, error) {
+	prof := startProfile("prove_ultrahonk_handle")
+	defer prof.done()
+
+	r, err := proveUltraHonk(bytecode, witnessJson, settings, prof)
+	if err != nil {
+		return nil, err
+	}
+	if err := resultErr(r); err != nil {
+		return nil, err
+	}
+	if r.data.ptr == nil || r.data.len == 0 {
+		C.bb_free_bytes(r.data)
+		return nil, errors.New("empty proof from backend")
+	}
+	return &ProofHandle{buf: r.data}, nil
+}
+
+// Len returns the size of the proof in bytes, or 0 if the handle has been freed.
+func (h *ProofHandle) Len() int {
+	return int(h.buf.len)
+}
+
+// Bytes returns a Go copy of the proof, in the same format returned by ProveUltraHonk.
+func (h *ProofHandle) Bytes() []byte {
+	if h.buf.ptr == nil {
+		return nil
+	}
+	return C.GoBytes(unsafe.Pointer(h.buf.ptr), C.int(h.buf.len))
+}
+
+// Free releases the native proof buffer. It is safe to call Free more than once.
+func (h *ProofHandle) Free() {
+	if h.buf.ptr == nil {
+		return
+	}
+	C.bb_free_bytes(h.buf)
+	h.buf = C.ByteBuffer{}
+}
+
+// VerifyUltraHonkHandle verifies a proof directly from native memory.
+// It returns false if the handle has been freed.
+func VerifyUltraHonkHandle(h *ProofHandle, vk []byte, settings ProofSystemSettings) bool {
+	if h == nil || h.buf.ptr == nil {
+		return false
+	}
+	return verifyUltraHonk(h.buf.ptr, int(h.buf.len), vk, settings) == nil
+}
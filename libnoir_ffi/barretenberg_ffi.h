@@ -20,14 +20,67 @@ typedef struct {
 void bb_free_bytes(ByteBuffer buf);
 void bb_free_err(char *s);
 
+/* Returns the version of this library, e.g. "0.1.0", as the data of the result. */
+BBResult bb_version(void);
+
+/* Drops the backend; the next call creates a new one from BB_BACKEND_TYPE. */
+void bb_reset_backend(void);
+
+/* Copies the stderr lines of the bb subprocess to fd; -1 discards them.
+ * A failed result of the pipe backend holds the last stderr lines of its command as its data. */
+void bb_set_pipe_stderr_fd(int32_t fd);
+
+/* Receives log lines and proving milestones; level is 0 debug, 1 info, 2 warn, 3 error.
+ * It may be called from any thread, and msg is only valid for the duration of the call. */
+typedef void (*BBLogCallback)(int32_t level, char *msg);
+
+/* Sets the log callback, or NULL to discard log output. */
+void bb_set_log_callback(BBLogCallback cb);
+
 BBResult bb_init_srs_from_bytecode(const char *bytecode_b64_gz);
 
+/* Loads the SRS: num_points uncompressed G1 points of 64 bytes and the 128-byte G2 point.
+ * The data is copied, so the buffers can be released once this returns. */
+BBResult bb_init_srs(const uint8_t *points_ptr, uint32_t num_points, const uint8_t *g2_ptr);
+
+/* Number of G1 points loaded with bb_init_srs, 0 if none was loaded since the backend started. */
+uint32_t bb_srs_num_points(void);
+
 BBResult bb_prove_ultrahonk(
     const char *bytecode_b64_gz,
     const char *witness_json,
     const char *settings_json
 );
 
+/* A witness passed to the native layer in chunks, so the caller never holds it whole. */
+typedef struct BBWitness BBWitness;
+
+/* Returns an empty witness, to be released with bb_witness_free. */
+BBWitness *bb_witness_new(void);
+
+/* Appends num_values 32-byte big-endian field elements at the next witness indices. */
+BBResult bb_witness_append(BBWitness *witness, const uint8_t *values, size_t num_values);
+
+/* Like bb_prove_ultrahonk, with a witness built by bb_witness_append.
+ * The values are moved out of witness, which is left empty. */
+BBResult bb_prove_ultrahonk_witness(
+    const char *bytecode_b64_gz,
+    BBWitness *witness,
+    const char *settings_json
+);
+
+void bb_witness_free(BBWitness *witness);
+
+/* Like bb_prove_ultrahonk, reusing a verification key computed with bb_get_vk_ultrahonk.
+ * With vk_len 0, the key is computed as bb_prove_ultrahonk does. */
+BBResult bb_prove_ultrahonk_with_vk(
+    const char *bytecode_b64_gz,
+    const char *witness_json,
+    const uint8_t *vk_ptr,
+    size_t vk_len,
+    const char *settings_json
+);
+
 BBResult bb_get_vk_ultrahonk(
     const char *bytecode_b64_gz,
     const char *settings_json
@@ -41,4 +94,94 @@ bool bb_verify_ultrahonk(
     const char *settings_json
 );
 
+/* Like bb_verify_ultrahonk, failing with the reason when the proof is not verified. */
+BBResult bb_verify_ultrahonk_err(
+    const uint8_t *proof_msgpack_ptr,
+    size_t proof_msgpack_len,
+    const uint8_t *vk_ptr,
+    size_t vk_len,
+    const char *settings_json
+);
+
+/* Pedersen commitment to num_inputs 32-byte big-endian field elements.
+ * Returns the commitment point as 64 bytes: x then y, big-endian. */
+BBResult bb_pedersen_commit(
+    const uint8_t *inputs_ptr,
+    size_t num_inputs,
+    uint32_t hash_index
+);
+
+/* Hash of a verification key's field representation, reduced into the scalar field.
+ * hash_type is "poseidon2" or "keccak". Returns 32 bytes, big-endian. */
+BBResult bb_vk_hash(
+    const uint8_t *vk_ptr,
+    size_t vk_len,
+    const char *hash_type
+);
+
+/* Field representation of a verification key, as used by recursive verifiers:
+ * the concatenated 32-byte big-endian elements. */
+BBResult bb_vk_as_fields(const uint8_t *vk_ptr, size_t vk_len);
+
+/* Structure of the main ACIR function, as JSON:
+ * {"num_opcodes", "current_witness_index", "private_parameters", "public_parameters", "return_values"} */
+BBResult bb_acir_info(const char *bytecode_b64_gz);
+
+/* Size of the circuit as built by the backend for the settings, as JSON:
+ * {"gate_count", "subgroup_size", "num_acir_opcodes"} */
+BBResult bb_circuit_info(const char *bytecode_b64_gz, const char *settings_json);
+
+/* Proves a small built-in circuit once to initialize the backend. Returns no data. */
+BBResult bb_warmup(const char *settings_json);
+
+/* Solidity source of the verifier contract for the VK, as UTF-8 text. */
+BBResult bb_write_solidity_verifier(
+    const uint8_t *vk_ptr,
+    size_t vk_len,
+    const char *settings_json
+);
+
+/* Solves the witness of the program's main function with the ACVM, starting from its
+ * num_inputs parameters given as 32-byte big-endian field elements. Returns the solved
+ * witness as 32-byte big-endian values, indexed by witness index. */
+BBResult bb_execute_witness(
+    const char *bytecode_b64_gz,
+    const uint8_t *inputs_ptr,
+    size_t num_inputs
+);
+
+/* Client IVC: accumulates num_circuits circuits, given in order with their witness JSON,
+ * and returns the msgpack-encoded composed proof. */
+BBResult bb_prove_client_ivc(
+    const char *const *bytecodes_b64_gz,
+    const char *const *witness_jsons,
+    size_t num_circuits
+);
+
+/* Client IVC verification key, derived from the last circuit of the accumulation. */
+BBResult bb_get_vk_client_ivc(const char *bytecode_b64_gz);
+
+bool bb_verify_client_ivc(
+    const uint8_t *proof_ptr,
+    size_t proof_len,
+    const uint8_t *vk_ptr,
+    size_t vk_len
+);
+
+/* A circuit prepared for repeated proving, with its bytecode decoded and VK computed once. */
+typedef struct BBProver BBProver;
+
+/* Prepares a prover for the circuit and stores it in *out; returns the VK.
+ * The prover must be released with bb_prover_free. */
+BBResult bb_prover_new(
+    const char *bytecode_b64_gz,
+    const char *settings_json,
+    BBProver **out
+);
+
+/* Like bb_prove_ultrahonk, for the circuit and settings of prover. */
+BBResult bb_prover_prove(const BBProver *prover, const char *witness_json);
+
+void bb_prover_free(BBProver *prover);
+
 #endif /* NOIR_FFI_H */
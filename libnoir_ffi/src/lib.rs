@@ -1,5 +1,4 @@
 use std::{ffi::{CStr, CString}, os::raw::c_char, ptr::null_mut};
-use once_cell::sync::OnceCell;
 use serde::{Deserialize, Serialize};
 use barretenberg_rs::BarretenbergApi;
 use barretenberg_rs::backends::PipeBackend;
@@ -10,6 +9,12 @@ use base64::{Engine as _, engine::general_purpose};
 use std::io::Read;
 use flate2::read::GzDecoder;
 use std::collections::BTreeMap;
+use std::sync::atomic::{AtomicBool, AtomicI32, AtomicU32, Ordering};
+use sha3::{Digest, Keccak256};
+use acir::{circuit::{Circuit, Opcode, Program, PublicInputs}, native_types::{Expression, Witness, WitnessMap}, AcirField, FieldElement};
+use acvm::pwg::{ACVMStatus, ACVM};
+use acvm::acir::brillig::ForeignCallResult;
+use bn254_blackbox_solver::Bn254BlackBoxSolver;
 
 enum ApiEnum {
     Pipe(BarretenbergApi<PipeBackend>),
@@ -17,7 +22,9 @@ enum ApiEnum {
     Native(BarretenbergApi<FfiBackend>),
 }
 
-static BB_API: OnceCell<std::sync::Mutex<ApiEnum>> = OnceCell::new();
+// The backend is created lazily on first use, reading BB_BACKEND_TYPE at that point.
+// bb_reset_backend drops it so the next call picks up a new backend type.
+static BB_API: std::sync::Mutex<Option<ApiEnum>> = std::sync::Mutex::new(None);
 
 fn find_bb_binary() -> String {
     if let Ok(p) = std::env::var("BB_BINARY_PATH") {
@@ -47,32 +54,178 @@ fn find_bb_binary() -> String {
     "bb".to_string()
 }
 
-fn get_api() -> Result<std::sync::MutexGuard<'static, ApiEnum>, String> {
-    let api_mutex = BB_API.get_or_init(|| {
-        let backend_type = std::env::var("BB_BACKEND_TYPE").unwrap_or_else(|_| "native".to_string());
-        
-        let api = if backend_type.to_lowercase() == "native" {
-            #[cfg(feature = "native-backend")]
-            {
-                let backend = FfiBackend::new().expect("Failed to create FfiBackend");
-                ApiEnum::Native(BarretenbergApi::new(backend))
-            }
-            #[cfg(not(feature = "native-backend"))]
-            {
-                let bb_path = find_bb_binary();
-                let backend = PipeBackend::new(&bb_path, Some(16)).expect("Failed to create PipeBackend");
-                ApiEnum::Pipe(BarretenbergApi::new(backend))
+// File descriptor the stderr lines of the bb subprocess are copied to, or -1 to discard them.
+static PIPE_STDERR_FD: AtomicI32 = AtomicI32::new(-1);
+
+// Number of recent bb stderr lines attached to the error of a failed command.
+const PIPE_STDERR_LINES: usize = 20;
+
+// Last stderr lines of the bb subprocess. Commands run one at a time, and the tail is taken
+// when each one finishes, so it only holds the output of the running command.
+static PIPE_STDERR_TAIL: std::sync::Mutex<std::collections::VecDeque<String>> = std::sync::Mutex::new(std::collections::VecDeque::new());
+
+// Read end of the bb stderr pipe, and whether the reader thread is handling lines it has read.
+static PIPE_STDERR_READER: AtomicI32 = AtomicI32::new(-1);
+static PIPE_STDERR_BUSY: AtomicBool = AtomicBool::new(false);
+
+// Longest wait, in milliseconds, for the stderr bb wrote during a command to be read.
+const PIPE_STDERR_DRAIN_MS: u32 = 50;
+
+thread_local! {
+    // Stderr tail of the last command that failed on this thread, handed to Go by err().
+    static CALL_STDERR: std::cell::RefCell<String> = std::cell::RefCell::new(String::new());
+}
+
+#[no_mangle]
+pub extern "C" fn bb_set_pipe_stderr_fd(fd: i32) {
+    // Writes never block, so a Go writer that falls behind drops lines rather than stalling bb.
+    if fd >= 0 {
+        unsafe { libc::fcntl(fd, libc::F_SETFL, libc::fcntl(fd, libc::F_GETFL) | libc::O_NONBLOCK) };
+    }
+    PIPE_STDERR_FD.store(fd, Ordering::SeqCst);
+}
+
+// Log levels passed to the log callback, matching LogLevel on the Go side.
+const LOG_DEBUG: i32 = 0;
+const LOG_INFO: i32 = 1;
+const LOG_WARN: i32 = 2;
+const LOG_ERROR: i32 = 3;
+
+type LogCallback = extern "C" fn(level: i32, msg: *const c_char);
+
+// Callback receiving log lines and proving milestones, or None to discard them.
+static LOG_CALLBACK: std::sync::RwLock<Option<LogCallback>> = std::sync::RwLock::new(None);
+
+#[no_mangle]
+pub extern "C" fn bb_set_log_callback(cb: Option<LogCallback>) {
+    *LOG_CALLBACK.write().unwrap_or_else(|e| e.into_inner()) = cb;
+}
+
+// Level of a bb stderr line, from the severity bb prefixes its warnings and errors with.
+fn stderr_line_level(line: &str) -> i32 {
+    let lower = line.trim_start().to_ascii_lowercase();
+    if lower.starts_with("error") || lower.contains("assertion failed") {
+        LOG_ERROR
+    } else if lower.starts_with("warn") {
+        LOG_WARN
+    } else {
+        LOG_INFO
+    }
+}
+
+fn log(level: i32, msg: &str) {
+    let cb = *LOG_CALLBACK.read().unwrap_or_else(|e| e.into_inner());
+    if let Some(cb) = cb {
+        if let Ok(msg) = CString::new(msg) {
+            cb(level, msg.as_ptr());
+        }
+    }
+}
+
+// Starts bb with its stderr connected to a pipe of its own, read line by line on a thread.
+// Our own stderr is left alone, so output of other threads is never captured.
+fn new_pipe_backend() -> Result<PipeBackend, String> {
+    use std::os::fd::{FromRawFd, OwnedFd};
+
+    let bb_path = find_bb_binary();
+    let mut fds = [0i32; 2];
+    if unsafe { libc::pipe(fds.as_mut_ptr()) } != 0 {
+        return Err(format!("Failed to create stderr pipe: {}", std::io::Error::last_os_error()));
+    }
+    let (reader, writer) = unsafe { (std::fs::File::from_raw_fd(fds[0]), OwnedFd::from_raw_fd(fds[1])) };
+    // The write end is moved into the child's Stdio and closed here once bb is started.
+    let backend = PipeBackend::with_stderr(&bb_path, Some(16), std::process::Stdio::from(writer))
+        .map_err(|e| format!("Failed to create PipeBackend: {}", e))?;
+
+    PIPE_STDERR_READER.store(fds[0], Ordering::SeqCst);
+
+    std::thread::spawn(move || {
+        let mut reader = reader;
+        let mut pending = Vec::new();
+        let mut buf = [0u8; 4096];
+        loop {
+            let n = match reader.read(&mut buf) {
+                Ok(0) | Err(_) => break,
+                Ok(n) => n,
+            };
+            PIPE_STDERR_BUSY.store(true, Ordering::SeqCst);
+            pending.extend_from_slice(&buf[..n]);
+            while let Some(i) = pending.iter().position(|&b| b == b'\n') {
+                let line: Vec<u8> = pending.drain(..=i).collect();
+                pipe_stderr_line(String::from_utf8_lossy(&line[..i]).into_owned());
             }
-        } else {
-            let bb_path = find_bb_binary();
-            let backend = PipeBackend::new(&bb_path, Some(16)).expect("Failed to create PipeBackend");
-            ApiEnum::Pipe(BarretenbergApi::new(backend))
-        };
-        
-        std::sync::Mutex::new(api)
+            PIPE_STDERR_BUSY.store(false, Ordering::SeqCst);
+        }
+        let _ = PIPE_STDERR_READER.compare_exchange(fds[0], -1, Ordering::SeqCst, Ordering::SeqCst);
     });
+    Ok(backend)
+}
+
+// Handles a stderr line of bb: copies it to Go, logs it and keeps it in the tail.
+fn pipe_stderr_line(line: String) {
+    let fd = PIPE_STDERR_FD.load(Ordering::SeqCst);
+    if fd >= 0 {
+        let out = format!("{}\n", line);
+        unsafe { libc::write(fd, out.as_ptr() as *const libc::c_void, out.len()) };
+    }
+    log(stderr_line_level(&line), &line);
+    let mut tail = PIPE_STDERR_TAIL.lock().unwrap_or_else(|e| e.into_inner());
+    tail.push_back(line);
+    if tail.len() > PIPE_STDERR_LINES {
+        tail.pop_front();
+    }
+}
+
+// Waits until the reader thread has handled everything bb wrote to stderr so far, for at most
+// PIPE_STDERR_DRAIN_MS, so the lines bb writes right before answering are charged to that command.
+// The pipe must look idle twice in a row, as the reader may have just read a chunk.
+fn drain_pipe_stderr() {
+    let fd = PIPE_STDERR_READER.load(Ordering::SeqCst);
+    if fd < 0 {
+        return;
+    }
+    let mut idle = 0;
+    for _ in 0..PIPE_STDERR_DRAIN_MS {
+        let mut unread: libc::c_int = 0;
+        let pending = unsafe { libc::ioctl(fd, libc::FIONREAD, &mut unread) } == 0 && unread > 0;
+        if pending || PIPE_STDERR_BUSY.load(Ordering::SeqCst) {
+            idle = 0;
+        } else {
+            idle += 1;
+            if idle == 2 {
+                return;
+            }
+        }
+        std::thread::sleep(std::time::Duration::from_millis(1));
+    }
+}
+
+fn new_api() -> Result<ApiEnum, String> {
+    let backend_type = std::env::var("BB_BACKEND_TYPE").unwrap_or_else(|_| "native".to_string());
     
-    api_mutex.lock().map_err(|e| format!("Mutex lock failed: {}", e))
+    let api = if backend_type.to_lowercase() == "native" {
+        #[cfg(feature = "native-backend")]
+        {
+            let backend = FfiBackend::new().map_err(|e| format!("Failed to create FfiBackend: {}", e))?;
+            ApiEnum::Native(BarretenbergApi::new(backend))
+        }
+        #[cfg(not(feature = "native-backend"))]
+        {
+            ApiEnum::Pipe(BarretenbergApi::new(new_pipe_backend()?))
+        }
+    } else {
+        ApiEnum::Pipe(BarretenbergApi::new(new_pipe_backend()?))
+    };
+    
+    Ok(api)
+}
+
+fn get_api() -> Result<std::sync::MutexGuard<'static, Option<ApiEnum>>, String> {
+    let mut guard = BB_API.lock().map_err(|e| format!("Mutex lock failed: {}", e))?;
+    if guard.is_none() {
+        *guard = Some(new_api()?);
+    }
+    Ok(guard)
 }
 
 #[repr(C)]
@@ -89,7 +242,9 @@ pub struct BBResult {
     pub data: ByteBuffer,
 }
 
+// Successful results drop the stderr of any failed command the call recovered from.
 fn ok(mut data: Vec<u8>) -> BBResult {
+    CALL_STDERR.with(|s| s.borrow_mut().clear());
     let len = data.len();
     let cap = data.capacity();
     let ptr = data.as_mut_ptr();
@@ -101,16 +256,20 @@ fn ok(mut data: Vec<u8>) -> BBResult {
     }
 }
 
+// Failed results carry the bb stderr tail of the failed command, if any, as their data.
 fn err(msg: String) -> BBResult {
     let c = CString::new(msg).unwrap_or_else(|_| CString::new("Unknown error").unwrap());
+    let mut stderr = CALL_STDERR.with(|s| std::mem::take(&mut *s.borrow_mut())).into_bytes();
+    let data = ByteBuffer {
+        ptr: if stderr.is_empty() { null_mut() } else { stderr.as_mut_ptr() },
+        len: stderr.len(),
+        cap: stderr.capacity(),
+    };
+    std::mem::forget(stderr);
     BBResult {
         ok: false,
         err: c.into_raw(),
-        data: ByteBuffer {
-            ptr: null_mut(),
-            len: 0,
-            cap: 0,
-        },
+        data,
     }
 }
 
@@ -132,6 +291,18 @@ pub extern "C" fn bb_free_err(s: *mut c_char) {
     }
 }
 
+// Number of G1 points of the SRS loaded with bb_init_srs, 0 if the backend loads its own.
+static SRS_POINTS: AtomicU32 = AtomicU32::new(0);
+
+#[no_mangle]
+pub extern "C" fn bb_reset_backend() {
+    // Dropping the backend also terminates the pipe subprocess, if any.
+    if let Ok(mut guard) = BB_API.lock() {
+        guard.take();
+        SRS_POINTS.store(0, Ordering::SeqCst);
+    }
+}
+
 unsafe fn cstr_to_string(p: *const c_char) -> Result<String, String> {
     if p.is_null() {
         return Err("null pointer".into());
@@ -142,16 +313,39 @@ unsafe fn cstr_to_string(p: *const c_char) -> Result<String, String> {
         .map_err(|e| e.to_string())
 }
 
+// Error messages start with one of these prefixes when the cause is known, so the Go bindings
+// can map them to error codes.
+const INVALID_BYTECODE: &str = "invalid bytecode: ";
+const INVALID_WITNESS: &str = "invalid witness: ";
+const SRS_NOT_INITIALIZED: &str = "srs not initialized: ";
+const UNSATISFIED_CONSTRAINT: &str = "unsatisfied constraint: ";
+const SRS_TOO_SMALL: &str = "srs too small: ";
+
 fn decode_bytecode(bytecode_b64_gz: &str) -> Result<Vec<u8>, String> {
     let compressed = general_purpose::STANDARD
         .decode(bytecode_b64_gz)
-        .map_err(|e| e.to_string())?;
+        .map_err(|e| format!("{}{}", INVALID_BYTECODE, e))?;
     let mut decoder = GzDecoder::new(&compressed[..]);
     let mut decompressed = Vec::new();
-    decoder.read_to_end(&mut decompressed).map_err(|e| e.to_string())?;
+    decoder.read_to_end(&mut decompressed).map_err(|e| format!("{}{}", INVALID_BYTECODE, e))?;
     Ok(decompressed)
 }
 
+// Settings as sent by the Go bindings: the backend's settings plus options handled by this shim.
+#[derive(Deserialize)]
+struct FfiSettings {
+    #[serde(flatten)]
+    settings: ProofSystemSettings,
+    // Hash of SolidityVKHash, computed by bb_vk_hash. The VK hash bb embeds follows the oracle hash.
+    #[serde(default)]
+    vk_hash_type: Option<String>,
+}
+
+unsafe fn parse_settings(settings_json: *const c_char) -> Result<FfiSettings, String> {
+    let settings_str = cstr_to_string(settings_json)?;
+    serde_json::from_str(&settings_str).map_err(|e| e.to_string())
+}
+
 #[no_mangle]
 pub extern "C" fn bb_init_srs_from_bytecode(_bytecode_b64_gz: *const c_char) -> BBResult {
     ok(vec![])
@@ -186,58 +380,216 @@ fn parse_field(s: &str) -> Result<[u8; 32], String> {
     Ok(arr)
 }
 
+// Parses a witness JSON `{"witness": [...]}` into a witness map indexed by position.
+unsafe fn parse_witness_json(witness_json: *const c_char) -> Result<BTreeMap<u32, serde_bytes::ByteBuf>, String> {
+    let wj_str = cstr_to_string(witness_json)?;
+    let parsed: WitnessJson = serde_json::from_str(&wj_str).map_err(|e| format!("{}{}", INVALID_WITNESS, e))?;
+
+    let mut witness_map = BTreeMap::new();
+    for (i, val_str) in parsed.witness.into_iter().enumerate() {
+        let field_bytes = parse_field(&val_str).map_err(|e| format!("{}value {}: {}", INVALID_WITNESS, i, e))?;
+        witness_map.insert(i as u32, serde_bytes::ByteBuf::from(field_bytes.to_vec()));
+    }
+    Ok(witness_map)
+}
+
 #[derive(Serialize)]
 struct WitnessMapWrapper(BTreeMap<u32, serde_bytes::ByteBuf>);
 
 #[derive(Serialize)]
 struct StackItemWrapper(u32, WitnessMapWrapper);
 
+// Dispatches a command to the typed API; shared by every backend variant.
+macro_rules! dispatch {
+    ($api:expr, $cmd:expr) => {
+        match $cmd {
+            Command::CircuitComputeVk(data) => {
+                $api.circuit_compute_vk(data.circuit, data.settings)
+                    .map(barretenberg_rs::generated_types::Response::CircuitComputeVkResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::CircuitProve(data) => {
+                $api.circuit_prove(data.circuit, &data.witness, data.settings)
+                    .map(barretenberg_rs::generated_types::Response::CircuitProveResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::CircuitVerify(data) => {
+                $api.circuit_verify(&data.verification_key, data.public_inputs, data.proof, data.settings)
+                    .map(barretenberg_rs::generated_types::Response::CircuitVerifyResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::PedersenCommit(data) => {
+                $api.pedersen_commit(data.inputs, data.hash_index)
+                    .map(barretenberg_rs::generated_types::Response::PedersenCommitResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::VkAsFields(data) => {
+                $api.vk_as_fields(data.verification_key)
+                    .map(barretenberg_rs::generated_types::Response::VkAsFieldsResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::Poseidon2Hash(data) => {
+                $api.poseidon2_hash(data.inputs)
+                    .map(barretenberg_rs::generated_types::Response::Poseidon2HashResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::CircuitWriteSolidityVerifier(data) => {
+                $api.circuit_write_solidity_verifier(data.verification_key, data.settings)
+                    .map(barretenberg_rs::generated_types::Response::CircuitWriteSolidityVerifierResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::CircuitStats(data) => {
+                $api.circuit_stats(data.circuit, data.include_gates_per_opcode, data.settings)
+                    .map(barretenberg_rs::generated_types::Response::CircuitInfoResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::SrsInitSrs(data) => {
+                $api.srs_init_srs(data.points_buf, data.num_points, data.g2_point)
+                    .map(barretenberg_rs::generated_types::Response::SrsInitSrsResponse)
+                    .map_err(|e| e.to_string())
+            }
+            // Client IVC is called Chonk in the backend's API.
+            Command::ChonkStart(data) => {
+                $api.chonk_start(data.num_circuits)
+                    .map(barretenberg_rs::generated_types::Response::ChonkStartResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::ChonkLoad(data) => {
+                $api.chonk_load(data.circuit)
+                    .map(barretenberg_rs::generated_types::Response::ChonkLoadResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::ChonkAccumulate(data) => {
+                $api.chonk_accumulate(data.witness)
+                    .map(barretenberg_rs::generated_types::Response::ChonkAccumulateResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::ChonkProve(_) => {
+                $api.chonk_prove()
+                    .map(barretenberg_rs::generated_types::Response::ChonkProveResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::ChonkVerify(data) => {
+                $api.chonk_verify(data.proof, data.vk)
+                    .map(barretenberg_rs::generated_types::Response::ChonkVerifyResponse)
+                    .map_err(|e| e.to_string())
+            }
+            Command::ChonkComputeIvcVk(data) => {
+                $api.chonk_compute_ivc_vk(data.circuit)
+                    .map(barretenberg_rs::generated_types::Response::ChonkComputeIvcVkResponse)
+                    .map_err(|e| e.to_string())
+            }
+            _ => Err("Unsupported command".to_string())
+        }
+    };
+}
+
 fn call_bb(cmd: Command) -> Result<barretenberg_rs::generated_types::Response, String> {
     let mut api_guard = get_api()?;
-    
-    match &mut *api_guard {
+    let api = api_guard.as_mut().ok_or("backend not initialized")?;
+    dispatch_cmd(api, cmd)
+}
+
+// Runs commands back to back, holding the backend so no other command runs in between.
+// The Client IVC commands share state in the backend and must not be interleaved.
+fn call_bb_seq(cmds: Vec<Command>) -> Result<Vec<barretenberg_rs::generated_types::Response>, String> {
+    let mut api_guard = get_api()?;
+    let api = api_guard.as_mut().ok_or("backend not initialized")?;
+    let n = cmds.len();
+    cmds.into_iter().enumerate().map(|(i, cmd)| {
+        log(LOG_DEBUG, &format!("running command {} of {}", i + 1, n));
+        dispatch_cmd(api, cmd)
+    }).collect()
+}
+
+fn dispatch_cmd(api: &mut ApiEnum, cmd: Command) -> Result<barretenberg_rs::generated_types::Response, String> {
+    CALL_STDERR.with(|s| s.borrow_mut().clear());
+    let res = match api {
         ApiEnum::Pipe(api) => {
-            match cmd {
-                Command::CircuitComputeVk(data) => {
-                    api.circuit_compute_vk(data.circuit, data.settings)
-                        .map(barretenberg_rs::generated_types::Response::CircuitComputeVkResponse)
-                        .map_err(|e| e.to_string())
-                }
-                Command::CircuitProve(data) => {
-                    api.circuit_prove(data.circuit, &data.witness, data.settings)
-                        .map(barretenberg_rs::generated_types::Response::CircuitProveResponse)
-                        .map_err(|e| e.to_string())
-                }
-                Command::CircuitVerify(data) => {
-                    api.circuit_verify(&data.verification_key, data.public_inputs, data.proof, data.settings)
-                        .map(barretenberg_rs::generated_types::Response::CircuitVerifyResponse)
-                        .map_err(|e| e.to_string())
-                }
-                _ => Err("Unsupported command".to_string())
+            let res = dispatch!(api, cmd);
+            // Take the output of this command whatever its result, so none is left for the next one.
+            drain_pipe_stderr();
+            let tail = std::mem::take(&mut *PIPE_STDERR_TAIL.lock().unwrap_or_else(|e| e.into_inner()));
+            if res.is_err() {
+                CALL_STDERR.with(|s| *s.borrow_mut() = Vec::from(tail).join("\n"));
             }
+            res
         }
         #[cfg(feature = "native-backend")]
-        ApiEnum::Native(api) => {
-            match cmd {
-                Command::CircuitComputeVk(data) => {
-                    api.circuit_compute_vk(data.circuit, data.settings)
-                        .map(barretenberg_rs::generated_types::Response::CircuitComputeVkResponse)
-                        .map_err(|e| e.to_string())
-                }
-                Command::CircuitProve(data) => {
-                    api.circuit_prove(data.circuit, &data.witness, data.settings)
-                        .map(barretenberg_rs::generated_types::Response::CircuitProveResponse)
-                        .map_err(|e| e.to_string())
-                }
-                Command::CircuitVerify(data) => {
-                    api.circuit_verify(&data.verification_key, data.public_inputs, data.proof, data.settings)
-                        .map(barretenberg_rs::generated_types::Response::CircuitVerifyResponse)
-                        .map_err(|e| e.to_string())
-                }
-                _ => Err("Unsupported command".to_string())
-            }
+        ApiEnum::Native(api) => dispatch!(api, cmd),
+    };
+    if let Err(e) = &res {
+        log(LOG_ERROR, e);
+    }
+    // The backend reports a missing or unreadable CRS in its own words, always naming the CRS.
+    res.map_err(|e| if e.contains("CRS") { format!("{}{}", SRS_NOT_INITIALIZED, e) } else { e })
+}
+
+// Encodes a witness map as the serialized witness stack expected by the prover.
+fn encode_witness(witness_map: BTreeMap<u32, serde_bytes::ByteBuf>) -> Result<Vec<u8>, String> {
+    let stack_item = StackItemWrapper(0, WitnessMapWrapper(witness_map));
+    
+    #[derive(Serialize)]
+    struct FinalWitnessStack {
+        stack: Vec<StackItemWrapper>,
+    }
+    let final_stack = FinalWitnessStack { stack: vec![stack_item] };
+
+    let encoded = rmp_serde::to_vec(&final_stack)
+        .map_err(|e| format!("Failed to serialize witness stack: {}", e))?;
+    let mut witness_bytes = vec![2u8]; 
+    witness_bytes.extend(encoded);
+    Ok(witness_bytes)
+}
+
+fn compute_vk(bytecode: Vec<u8>, settings: ProofSystemSettings) -> Result<Vec<u8>, String> {
+    // The backend would fail deep inside the commitment code, so check a loaded SRS upfront.
+    let srs_points = SRS_POINTS.load(Ordering::SeqCst);
+    if srs_points > 0 {
+        let stats = circuit_stats(bytecode.clone(), settings.clone())?;
+        if stats.num_gates_dyadic as u64 > srs_points as u64 {
+            return Err(format!("{}circuit needs {} points, the SRS has {}", SRS_TOO_SMALL, stats.num_gates_dyadic, srs_points));
         }
     }
+
+    let circuit_input = CircuitInputNoVK {
+        name: "circuit".to_string(),
+        bytecode,
+    };
+
+    log(LOG_INFO, "computing verification key");
+    let vk_resp = match call_bb(Command::CircuitComputeVk(barretenberg_rs::generated_types::CircuitComputeVk::new(circuit_input, settings)))? {
+        barretenberg_rs::generated_types::Response::CircuitComputeVkResponse(r) => r,
+        _ => return Err("Unexpected response".to_string()),
+    };
+    Ok(vk_resp.bytes)
+}
+
+// Proves a circuit and returns the msgpack-encoded response handed to Go as the proof.
+fn prove(bytecode: Vec<u8>, witness_map: BTreeMap<u32, serde_bytes::ByteBuf>, settings: ProofSystemSettings) -> Result<Vec<u8>, String> {
+    let vk = compute_vk(bytecode.clone(), settings.clone())?;
+    prove_with_vk(bytecode, vk, witness_map, settings)
+}
+
+// Like prove, with the verification key already computed.
+fn prove_with_vk(bytecode: Vec<u8>, vk: Vec<u8>, witness_map: BTreeMap<u32, serde_bytes::ByteBuf>, settings: ProofSystemSettings) -> Result<Vec<u8>, String> {
+    let witness_bytes = encode_witness(witness_map)?;
+
+    let circuit_input = CircuitInput {
+        name: "circuit".to_string(),
+        bytecode,
+        verification_key: vk,
+    };
+
+    log(LOG_INFO, "constructing proof");
+    let prove_resp = match call_bb(Command::CircuitProve(barretenberg_rs::generated_types::CircuitProve::new(circuit_input, witness_bytes, settings)))? {
+        barretenberg_rs::generated_types::Response::CircuitProveResponse(r) => r,
+        _ => return Err("Unexpected response".to_string()),
+    };
+    log(LOG_INFO, &format!("proof constructed: {} public inputs, {} elements", prove_resp.public_inputs.len(), prove_resp.proof.len()));
+
+    rmp_serde::to_vec_named(&prove_resp)
+        .map_err(|e| format!("Failed to serialize response: {}", e))
 }
 
 #[no_mangle]
@@ -250,56 +602,100 @@ pub extern "C" fn bb_prove_ultrahonk(
         let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
         let bytecode = decode_bytecode(&bytecode_str)?;
         
-        let wj_str = unsafe { cstr_to_string(witness_json) }?;
-        let parsed: WitnessJson = serde_json::from_str(&wj_str).map_err(|e| e.to_string())?;
+        let witness_map = unsafe { parse_witness_json(witness_json) }?;
 
-        let settings_str = unsafe { cstr_to_string(settings_json) }?;
-        let settings: ProofSystemSettings = serde_json::from_str(&settings_str).map_err(|e| e.to_string())?;
+        let settings = unsafe { parse_settings(settings_json) }?.settings;
 
-        let mut witness_map = BTreeMap::new();
-        for (i, val_str) in parsed.witness.into_iter().enumerate() {
-            let field_bytes = parse_field(&val_str)?;
-            witness_map.insert(i as u32, serde_bytes::ByteBuf::from(field_bytes.to_vec()));
-        }
+        prove(bytecode, witness_map, settings)
+    })();
 
-        let stack_item = StackItemWrapper(0, WitnessMapWrapper(witness_map));
-        
-        #[derive(Serialize)]
-        struct FinalWitnessStack {
-            stack: Vec<StackItemWrapper>,
+    match res {
+        Ok(p) => ok(p),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_prove_ultrahonk_with_vk(
+    bytecode_b64_gz: *const c_char,
+    witness_json: *const c_char,
+    vk_ptr: *const u8,
+    vk_len: usize,
+    settings_json: *const c_char,
+) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
+        let bytecode = decode_bytecode(&bytecode_str)?;
+
+        let witness_map = unsafe { parse_witness_json(witness_json) }?;
+
+        let settings = unsafe { parse_settings(settings_json) }?.settings;
+
+        if vk_len == 0 {
+            return prove(bytecode, witness_map, settings);
+        }
+        if vk_ptr.is_null() {
+            return Err("null pointer".into());
         }
-        let final_stack = FinalWitnessStack { stack: vec![stack_item] };
+        let vk = unsafe { std::slice::from_raw_parts(vk_ptr, vk_len) }.to_vec();
+        prove_with_vk(bytecode, vk, witness_map, settings)
+    })();
 
-        let encoded = rmp_serde::to_vec(&final_stack)
-            .map_err(|e| format!("Failed to serialize witness stack: {}", e))?;
-        let mut witness_bytes = vec![2u8]; 
-        witness_bytes.extend(encoded);
+    match res {
+        Ok(p) => ok(p),
+        Err(e) => err(e),
+    }
+}
 
-        let circuit_input_no_vk = CircuitInputNoVK {
-            name: "circuit".to_string(),
-            bytecode: bytecode.clone(), 
-        };
+// A witness appended to in chunks by bb_witness_append, indexed from 0.
+#[derive(Default)]
+pub struct BBWitness {
+    values: BTreeMap<u32, serde_bytes::ByteBuf>,
+}
 
-        let vk_resp = match call_bb(Command::CircuitComputeVk(barretenberg_rs::generated_types::CircuitComputeVk::new(circuit_input_no_vk, settings.clone())))? {
-            barretenberg_rs::generated_types::Response::CircuitComputeVkResponse(r) => r,
-            _ => return Err("Unexpected response".to_string()),
-        };
+#[no_mangle]
+pub extern "C" fn bb_witness_new() -> *mut BBWitness {
+    Box::into_raw(Box::new(BBWitness::default()))
+}
 
-        let circuit_input = CircuitInput {
-            name: "circuit".to_string(),
-            bytecode,
-            verification_key: vk_resp.bytes,
-        };
+#[no_mangle]
+pub extern "C" fn bb_witness_append(witness: *mut BBWitness, values: *const u8, num_values: usize) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        let witness = unsafe { witness.as_mut() }.ok_or("null pointer")?;
+        if values.is_null() {
+            return Err("null pointer".into());
+        }
+        let next = witness.values.len();
+        if next + num_values > u32::MAX as usize {
+            return Err(format!("invalid witness: more than {} values", u32::MAX));
+        }
+        let raw = unsafe { std::slice::from_raw_parts(values, num_values * 32) };
+        for (i, v) in raw.chunks(32).enumerate() {
+            witness.values.insert((next + i) as u32, serde_bytes::ByteBuf::from(v.to_vec()));
+        }
+        Ok(Vec::new())
+    })();
 
-        let prove_resp = match call_bb(Command::CircuitProve(barretenberg_rs::generated_types::CircuitProve::new(circuit_input, witness_bytes, settings)))? {
-            barretenberg_rs::generated_types::Response::CircuitProveResponse(r) => r,
-            _ => return Err("Unexpected response".to_string()),
-        };
+    match res {
+        Ok(v) => ok(v),
+        Err(e) => err(e),
+    }
+}
 
-        let resp_bytes = rmp_serde::to_vec_named(&prove_resp)
-            .map_err(|e| format!("Failed to serialize response: {}", e))?;
-        
-        Ok(resp_bytes)
+#[no_mangle]
+pub extern "C" fn bb_prove_ultrahonk_witness(
+    bytecode_b64_gz: *const c_char,
+    witness: *mut BBWitness,
+    settings_json: *const c_char,
+) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        let witness = unsafe { witness.as_mut() }.ok_or("null pointer")?;
+        let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
+        let bytecode = decode_bytecode(&bytecode_str)?;
+
+        let settings = unsafe { parse_settings(settings_json) }?.settings;
+
+        prove(bytecode, std::mem::take(&mut witness.values), settings)
     })();
 
     match res {
@@ -308,6 +704,15 @@ pub extern "C" fn bb_prove_ultrahonk(
     }
 }
 
+#[no_mangle]
+pub extern "C" fn bb_witness_free(witness: *mut BBWitness) {
+    if !witness.is_null() {
+        unsafe {
+            drop(Box::from_raw(witness));
+        }
+    }
+}
+
 #[no_mangle]
 pub extern "C" fn bb_get_vk_ultrahonk(
     bytecode_b64_gz: *const c_char,
@@ -317,20 +722,9 @@ pub extern "C" fn bb_get_vk_ultrahonk(
         let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
         let bytecode = decode_bytecode(&bytecode_str)?;
         
-        let settings_str = unsafe { cstr_to_string(settings_json) }?;
-        let settings: ProofSystemSettings = serde_json::from_str(&settings_str).map_err(|e| e.to_string())?;
-
-        let circuit_input = CircuitInputNoVK {
-            name: "circuit".to_string(),
-            bytecode,
-        };
+        let settings = unsafe { parse_settings(settings_json) }?.settings;
 
-        let vk_resp = match call_bb(Command::CircuitComputeVk(barretenberg_rs::generated_types::CircuitComputeVk::new(circuit_input, settings)))? {
-            barretenberg_rs::generated_types::Response::CircuitComputeVkResponse(r) => r,
-            _ => return Err("Unexpected response".to_string()),
-        };
-            
-        Ok(vk_resp.bytes)
+        compute_vk(bytecode, settings)
     })();
 
     match res {
@@ -339,6 +733,19 @@ pub extern "C" fn bb_get_vk_ultrahonk(
     }
 }
 
+const INVALID_PROOF: &str = "invalid proof: ";
+
+fn verify(proof_msgpack: &[u8], vk_bytes: Vec<u8>, settings: ProofSystemSettings) -> Result<bool, String> {
+    let prove_resp: CircuitProveResponse = rmp_serde::from_slice(proof_msgpack)
+        .map_err(|e| format!("{}failed to deserialize proof response: {}", INVALID_PROOF, e))?;
+
+    let verified = match call_bb(Command::CircuitVerify(barretenberg_rs::generated_types::CircuitVerify::new(vk_bytes, prove_resp.public_inputs, prove_resp.proof, settings)))? {
+        barretenberg_rs::generated_types::Response::CircuitVerifyResponse(r) => r,
+        _ => return Err("Unexpected response".to_string()),
+    };
+    Ok(verified.verified)
+}
+
 #[no_mangle]
 pub extern "C" fn bb_verify_ultrahonk(
     proof_msgpack_ptr: *const u8,
@@ -347,25 +754,569 @@ pub extern "C" fn bb_verify_ultrahonk(
     vk_len: usize,
     settings_json: *const c_char,
 ) -> bool {
+    let res = bb_verify_ultrahonk_err(proof_msgpack_ptr, proof_msgpack_len, vk_ptr, vk_len, settings_json);
+    let verified = res.ok;
+    bb_free_err(res.err);
+    bb_free_bytes(res.data);
+    verified
+}
+
+#[no_mangle]
+pub extern "C" fn bb_verify_ultrahonk_err(
+    proof_msgpack_ptr: *const u8,
+    proof_msgpack_len: usize,
+    vk_ptr: *const u8,
+    vk_len: usize,
+    settings_json: *const c_char,
+) -> BBResult {
     let res: Result<bool, String> = (|| {
         if proof_msgpack_ptr.is_null() || vk_ptr.is_null() {
             return Err("null pointer".into());
         }
         let proof_msgpack = unsafe { std::slice::from_raw_parts(proof_msgpack_ptr, proof_msgpack_len) };
         let vk_bytes = unsafe { std::slice::from_raw_parts(vk_ptr, vk_len) }.to_vec();
-        
-        let settings_str = unsafe { cstr_to_string(settings_json) }?;
-        let settings: ProofSystemSettings = serde_json::from_str(&settings_str).map_err(|e| e.to_string())?;
 
-        let prove_resp: CircuitProveResponse = rmp_serde::from_slice(proof_msgpack)
-            .map_err(|e| format!("Failed to deserialize proof response: {}", e))?;
+        let settings = unsafe { parse_settings(settings_json) }?.settings;
+
+        verify(proof_msgpack, vk_bytes, settings)
+    })();
+
+    match res {
+        Ok(true) => ok(vec![]),
+        Ok(false) => err(format!("{}proof does not verify", INVALID_PROOF)),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_pedersen_commit(
+    inputs_ptr: *const u8,
+    num_inputs: usize,
+    hash_index: u32,
+) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        if inputs_ptr.is_null() {
+            return Err("null pointer".into());
+        }
+        let raw = unsafe { std::slice::from_raw_parts(inputs_ptr, num_inputs * 32) };
+        let inputs: Vec<Vec<u8>> = raw.chunks(32).map(|c| c.to_vec()).collect();
 
-        let verified = match call_bb(Command::CircuitVerify(barretenberg_rs::generated_types::CircuitVerify::new(vk_bytes, prove_resp.public_inputs, prove_resp.proof, settings)))? {
-            barretenberg_rs::generated_types::Response::CircuitVerifyResponse(r) => r,
+        let commit_resp = match call_bb(Command::PedersenCommit(barretenberg_rs::generated_types::PedersenCommit::new(inputs, hash_index)))? {
+            barretenberg_rs::generated_types::Response::PedersenCommitResponse(r) => r,
             _ => return Err("Unexpected response".to_string()),
         };
-            
-        Ok(verified.verified)
+
+        let mut point = commit_resp.point.x;
+        point.extend(commit_resp.point.y);
+        Ok(point)
+    })();
+
+    match res {
+        Ok(p) => ok(p),
+        Err(e) => err(e),
+    }
+}
+
+// Order of the bn254 scalar field, as big-endian 64-bit limbs.
+const FR_MODULUS: [u64; 4] = [
+    0x30644e72e131a029,
+    0xb85045b68181585d,
+    0x2833e84879b97091,
+    0x43e1f593f0000001,
+];
+
+// Reduces a 256-bit big-endian value into the scalar field. Any such value is below 6 times
+// the modulus, so a few conditional subtractions suffice.
+fn reduce_fr(bytes: [u8; 32]) -> Vec<u8> {
+    let mut v = [0u64; 4];
+    for (i, limb) in v.iter_mut().enumerate() {
+        *limb = u64::from_be_bytes(bytes[i * 8..i * 8 + 8].try_into().unwrap());
+    }
+    while v >= FR_MODULUS {
+        let mut borrow = false;
+        for i in (0..4).rev() {
+            let (d, b1) = v[i].overflowing_sub(FR_MODULUS[i]);
+            let (d, b2) = d.overflowing_sub(borrow as u64);
+            v[i] = d;
+            borrow = b1 || b2;
+        }
+    }
+    v.iter().flat_map(|l| l.to_be_bytes()).collect()
+}
+
+fn vk_fields(vk: Vec<u8>) -> Result<Vec<Vec<u8>>, String> {
+    match call_bb(Command::VkAsFields(barretenberg_rs::generated_types::VkAsFields::new(vk)))? {
+        barretenberg_rs::generated_types::Response::VkAsFieldsResponse(r) => Ok(r.fields),
+        _ => Err("Unexpected response".to_string()),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_vk_hash(
+    vk_ptr: *const u8,
+    vk_len: usize,
+    hash_type: *const c_char,
+) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        if vk_ptr.is_null() {
+            return Err("null pointer".into());
+        }
+        let vk_bytes = unsafe { std::slice::from_raw_parts(vk_ptr, vk_len) }.to_vec();
+        let hash_type = unsafe { cstr_to_string(hash_type) }?;
+        let fields = vk_fields(vk_bytes)?;
+
+        match hash_type.as_str() {
+            "poseidon2" => {
+                match call_bb(Command::Poseidon2Hash(barretenberg_rs::generated_types::Poseidon2Hash::new(fields)))? {
+                    barretenberg_rs::generated_types::Response::Poseidon2HashResponse(r) => Ok(r.hash),
+                    _ => Err("Unexpected response".to_string()),
+                }
+            }
+            "keccak" => {
+                let mut hasher = Keccak256::new();
+                for f in &fields {
+                    hasher.update(f);
+                }
+                Ok(reduce_fr(hasher.finalize().into()))
+            }
+            other => Err(format!("unsupported vk hash type: {}", other)),
+        }
+    })();
+
+    match res {
+        Ok(h) => ok(h),
+        Err(e) => err(e),
+    }
+}
+
+#[derive(Serialize)]
+struct AcirInfo {
+    num_opcodes: usize,
+    current_witness_index: u32,
+    private_parameters: Vec<u32>,
+    public_parameters: Vec<u32>,
+    return_values: Vec<u32>,
+}
+
+fn acir_info(bytecode_b64_gz: &str) -> Result<AcirInfo, String> {
+    // The ACIR deserializer expects the gzipped program, so only the base64 layer is removed.
+    let compressed = general_purpose::STANDARD
+        .decode(bytecode_b64_gz)
+        .map_err(|e| format!("{}{}", INVALID_BYTECODE, e))?;
+    let program: Program<FieldElement> = Program::deserialize_program(&compressed)
+        .map_err(|e| format!("{}failed to deserialize program: {}", INVALID_BYTECODE, e))?;
+    let main = program.functions.first().ok_or("program has no functions")?;
+
+    Ok(AcirInfo {
+        num_opcodes: main.opcodes.len(),
+        current_witness_index: main.current_witness_index,
+        private_parameters: main.private_parameters.iter().map(|w| w.witness_index()).collect(),
+        public_parameters: main.public_parameters.0.iter().map(|w| w.witness_index()).collect(),
+        return_values: main.return_values.0.iter().map(|w| w.witness_index()).collect(),
+    })
+}
+
+#[no_mangle]
+pub extern "C" fn bb_acir_info(bytecode_b64_gz: *const c_char) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
+        let info = acir_info(&bytecode_str)?;
+        serde_json::to_vec(&info).map_err(|e| e.to_string())
+    })();
+
+    match res {
+        Ok(j) => ok(j),
+        Err(e) => err(e),
+    }
+}
+
+// A minimal circuit, x * x == y with y public, built in place so warming up needs no artifact.
+fn warmup_program() -> Vec<u8> {
+    let (x, y) = (Witness(0), Witness(1));
+    let circuit = Circuit {
+        current_witness_index: 1,
+        opcodes: vec![Opcode::AssertZero(Expression {
+            mul_terms: vec![(FieldElement::one(), x, x)],
+            linear_combinations: vec![(-FieldElement::one(), y)],
+            q_c: FieldElement::zero(),
+        })],
+        private_parameters: [x].into_iter().collect(),
+        public_parameters: PublicInputs([y].into_iter().collect()),
+        ..Circuit::default()
+    };
+    let program = Program { functions: vec![circuit], unconstrained_functions: vec![] };
+    Program::serialize_program(&program)
+}
+
+#[no_mangle]
+pub extern "C" fn bb_warmup(settings_json: *const c_char) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        let settings = unsafe { parse_settings(settings_json) }?.settings;
+
+        let mut decoder = GzDecoder::new(&warmup_program()[..]);
+        let mut bytecode = Vec::new();
+        decoder.read_to_end(&mut bytecode).map_err(|e| e.to_string())?;
+
+        let mut witness_map = BTreeMap::new();
+        for (i, v) in [3u8, 9u8].into_iter().enumerate() {
+            let mut field = [0u8; 32];
+            field[31] = v;
+            witness_map.insert(i as u32, serde_bytes::ByteBuf::from(field.to_vec()));
+        }
+        prove(bytecode, witness_map, settings)?;
+        Ok(vec![])
+    })();
+
+    match res {
+        Ok(v) => ok(v),
+        Err(e) => err(e),
+    }
+}
+
+// A circuit prepared for repeated proving: the bytecode is decoded and the VK computed once.
+pub struct BBProver {
+    bytecode: Vec<u8>,
+    vk: Vec<u8>,
+    settings: ProofSystemSettings,
+}
+
+#[no_mangle]
+pub extern "C" fn bb_prover_new(
+    bytecode_b64_gz: *const c_char,
+    settings_json: *const c_char,
+    out: *mut *mut BBProver,
+) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        if out.is_null() {
+            return Err("null pointer".into());
+        }
+        let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
+        let bytecode = decode_bytecode(&bytecode_str)?;
+
+        let settings = unsafe { parse_settings(settings_json) }?.settings;
+
+        let vk = compute_vk(bytecode.clone(), settings.clone())?;
+        let prover = Box::new(BBProver { bytecode, vk: vk.clone(), settings });
+        unsafe { *out = Box::into_raw(prover) };
+        Ok(vk)
+    })();
+
+    match res {
+        Ok(v) => ok(v),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_prover_prove(prover: *const BBProver, witness_json: *const c_char) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        let prover = unsafe { prover.as_ref() }.ok_or("null pointer")?;
+        let witness_map = unsafe { parse_witness_json(witness_json) }?;
+
+        prove_with_vk(prover.bytecode.clone(), prover.vk.clone(), witness_map, prover.settings.clone())
+    })();
+
+    match res {
+        Ok(p) => ok(p),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_prover_free(prover: *mut BBProver) {
+    if !prover.is_null() {
+        unsafe {
+            drop(Box::from_raw(prover));
+        }
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_write_solidity_verifier(
+    vk_ptr: *const u8,
+    vk_len: usize,
+    settings_json: *const c_char,
+) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        if vk_ptr.is_null() {
+            return Err("null pointer".into());
+        }
+        let vk_bytes = unsafe { std::slice::from_raw_parts(vk_ptr, vk_len) }.to_vec();
+        let ffi_settings = unsafe { parse_settings(settings_json) }?;
+        // bb takes no separate VK hash, the contract embeds one using the oracle hash.
+        if let Some(h) = ffi_settings.vk_hash_type.as_deref().filter(|h| *h != "keccak") {
+            return Err(format!("the solidity verifier embeds the keccak VK hash, got vk_hash_type {}", h));
+        }
+        let settings = ffi_settings.settings;
+
+        match call_bb(Command::CircuitWriteSolidityVerifier(barretenberg_rs::generated_types::CircuitWriteSolidityVerifier::new(vk_bytes, settings)))? {
+            barretenberg_rs::generated_types::Response::CircuitWriteSolidityVerifierResponse(r) => Ok(r.solidity_code.into_bytes()),
+            _ => Err("Unexpected response".to_string()),
+        }
+    })();
+
+    match res {
+        Ok(code) => ok(code),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_vk_as_fields(vk_ptr: *const u8, vk_len: usize) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        if vk_ptr.is_null() {
+            return Err("null pointer".into());
+        }
+        let vk_bytes = unsafe { std::slice::from_raw_parts(vk_ptr, vk_len) }.to_vec();
+        Ok(vk_fields(vk_bytes)?.concat())
+    })();
+
+    match res {
+        Ok(f) => ok(f),
+        Err(e) => err(e),
+    }
+}
+
+// Solves the witness of the main function from its initial witness with the ACVM.
+fn execute(bytecode_b64_gz: &str, initial_witness: WitnessMap<FieldElement>) -> Result<Vec<u8>, String> {
+    let compressed = general_purpose::STANDARD
+        .decode(bytecode_b64_gz)
+        .map_err(|e| format!("{}{}", INVALID_BYTECODE, e))?;
+    let program: Program<FieldElement> = Program::deserialize_program(&compressed)
+        .map_err(|e| format!("{}failed to deserialize program: {}", INVALID_BYTECODE, e))?;
+    let main = program.functions.first().ok_or(format!("{}program has no functions", INVALID_BYTECODE))?;
+
+    let solver = Bn254BlackBoxSolver(false);
+    let mut acvm = ACVM::new(&solver, &main.opcodes, initial_witness, &program.unconstrained_functions, &main.assert_messages);
+    loop {
+        match acvm.solve() {
+            ACVMStatus::Solved => break,
+            ACVMStatus::Failure(e) => return Err(format!("{}{}", UNSATISFIED_CONSTRAINT, e)),
+            ACVMStatus::RequiresForeignCall(call) => {
+                // Printing is the only oracle a plain circuit can use; it has no result.
+                if call.function != "print" {
+                    return Err(format!("unsupported foreign call: {}", call.function));
+                }
+                acvm.resolve_pending_foreign_call(ForeignCallResult::default());
+            }
+            ACVMStatus::RequiresAcirCall(_) => {
+                return Err("programs calling other ACIR functions are not supported".into());
+            }
+            ACVMStatus::InProgress => unreachable!("solve returns once the ACVM stops"),
+        }
+    }
+
+    let solved = acvm.finalize();
+    let mut out = vec![0u8; (main.current_witness_index as usize + 1) * 32];
+    for (w, v) in solved.into_iter() {
+        let i = w.witness_index() as usize;
+        if i <= main.current_witness_index as usize {
+            out[i * 32..(i + 1) * 32].copy_from_slice(&v.to_be_bytes());
+        }
+    }
+    Ok(out)
+}
+
+#[no_mangle]
+pub extern "C" fn bb_execute_witness(
+    bytecode_b64_gz: *const c_char,
+    inputs_ptr: *const u8,
+    num_inputs: usize,
+) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        if inputs_ptr.is_null() && num_inputs > 0 {
+            return Err("null pointer".into());
+        }
+        let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
+
+        let mut initial_witness = WitnessMap::new();
+        if num_inputs > 0 {
+            let raw = unsafe { std::slice::from_raw_parts(inputs_ptr, num_inputs * 32) };
+            for (i, v) in raw.chunks(32).enumerate() {
+                initial_witness.insert(Witness(i as u32), FieldElement::from_be_bytes_reduce(v));
+            }
+        }
+
+        execute(&bytecode_str, initial_witness)
+    })();
+
+    match res {
+        Ok(w) => ok(w),
+        Err(e) => err(e),
+    }
+}
+
+// Builds the circuit to count its gates, without computing a proving key or proof.
+fn circuit_stats(bytecode: Vec<u8>, settings: ProofSystemSettings) -> Result<barretenberg_rs::generated_types::CircuitInfoResponse, String> {
+    let circuit_input = CircuitInput {
+        name: "circuit".to_string(),
+        bytecode,
+        verification_key: vec![],
+    };
+    match call_bb(Command::CircuitStats(barretenberg_rs::generated_types::CircuitStats::new(circuit_input, false, settings)))? {
+        barretenberg_rs::generated_types::Response::CircuitInfoResponse(r) => Ok(r),
+        _ => Err("Unexpected response".to_string()),
+    }
+}
+
+#[derive(Serialize)]
+struct CircuitInfo {
+    gate_count: u64,
+    subgroup_size: u64,
+    num_acir_opcodes: u32,
+}
+
+#[no_mangle]
+pub extern "C" fn bb_circuit_info(bytecode_b64_gz: *const c_char, settings_json: *const c_char) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
+        let bytecode = decode_bytecode(&bytecode_str)?;
+
+        let settings = unsafe { parse_settings(settings_json) }?.settings;
+
+        let stats = circuit_stats(bytecode, settings)?;
+
+        let info = CircuitInfo {
+            gate_count: stats.num_gates as u64,
+            subgroup_size: stats.num_gates_dyadic as u64,
+            num_acir_opcodes: stats.num_acir_opcodes as u32,
+        };
+        serde_json::to_vec(&info).map_err(|e| e.to_string())
+    })();
+
+    match res {
+        Ok(j) => ok(j),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_init_srs(points_ptr: *const u8, num_points: u32, g2_ptr: *const u8) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        if points_ptr.is_null() || g2_ptr.is_null() {
+            return Err("null pointer".into());
+        }
+        let points = unsafe { std::slice::from_raw_parts(points_ptr, num_points as usize * 64) }.to_vec();
+        let g2 = unsafe { std::slice::from_raw_parts(g2_ptr, 128) }.to_vec();
+
+        match call_bb(Command::SrsInitSrs(barretenberg_rs::generated_types::SrsInitSrs::new(points, num_points, g2)))? {
+            barretenberg_rs::generated_types::Response::SrsInitSrsResponse(_) => {
+                SRS_POINTS.store(num_points, Ordering::SeqCst);
+                Ok(vec![])
+            }
+            _ => Err("Unexpected response".to_string()),
+        }
+    })();
+
+    match res {
+        Ok(v) => ok(v),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_version() -> BBResult {
+    ok(env!("CARGO_PKG_VERSION").as_bytes().to_vec())
+}
+
+#[no_mangle]
+pub extern "C" fn bb_srs_num_points() -> u32 {
+    SRS_POINTS.load(Ordering::SeqCst)
+}
+
+// Reads the num_circuits C strings of an array.
+unsafe fn cstr_array(ptr: *const *const c_char, num_circuits: usize) -> Result<Vec<*const c_char>, String> {
+    if ptr.is_null() {
+        return Err("null pointer".into());
+    }
+    Ok(std::slice::from_raw_parts(ptr, num_circuits).to_vec())
+}
+
+#[no_mangle]
+pub extern "C" fn bb_prove_client_ivc(
+    bytecodes_b64_gz: *const *const c_char,
+    witness_jsons: *const *const c_char,
+    num_circuits: usize,
+) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        if num_circuits == 0 {
+            return Err("no circuits to accumulate".into());
+        }
+        let bytecodes = unsafe { cstr_array(bytecodes_b64_gz, num_circuits) }?;
+        let witnesses = unsafe { cstr_array(witness_jsons, num_circuits) }?;
+
+        let mut cmds = vec![Command::ChonkStart(barretenberg_rs::generated_types::ChonkStart::new(num_circuits as u32))];
+        for (i, (bytecode, witness)) in bytecodes.into_iter().zip(witnesses).enumerate() {
+            let bytecode_str = unsafe { cstr_to_string(bytecode) }?;
+            let bytecode = decode_bytecode(&bytecode_str).map_err(|e| format!("circuit {}: {}", i, e))?;
+            let witness_map = unsafe { parse_witness_json(witness) }.map_err(|e| format!("circuit {}: {}", i, e))?;
+
+            // An empty key lets the backend compute the circuit's VK as it accumulates it.
+            let circuit = CircuitInput {
+                name: format!("circuit_{}", i),
+                bytecode,
+                verification_key: vec![],
+            };
+            cmds.push(Command::ChonkLoad(barretenberg_rs::generated_types::ChonkLoad::new(circuit)));
+            cmds.push(Command::ChonkAccumulate(barretenberg_rs::generated_types::ChonkAccumulate::new(encode_witness(witness_map)?)));
+        }
+        cmds.push(Command::ChonkProve(barretenberg_rs::generated_types::ChonkProve::new()));
+
+        match call_bb_seq(cmds)?.pop() {
+            Some(barretenberg_rs::generated_types::Response::ChonkProveResponse(r)) => rmp_serde::to_vec_named(&r.proof)
+                .map_err(|e| format!("Failed to serialize proof: {}", e)),
+            _ => Err("Unexpected response".to_string()),
+        }
+    })();
+
+    match res {
+        Ok(p) => ok(p),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_get_vk_client_ivc(bytecode_b64_gz: *const c_char) -> BBResult {
+    let res: Result<Vec<u8>, String> = (|| {
+        let bytecode_str = unsafe { cstr_to_string(bytecode_b64_gz) }?;
+        let bytecode = decode_bytecode(&bytecode_str)?;
+
+        let circuit = CircuitInputNoVK {
+            name: "circuit".to_string(),
+            bytecode,
+        };
+        match call_bb(Command::ChonkComputeIvcVk(barretenberg_rs::generated_types::ChonkComputeIvcVk::new(circuit)))? {
+            barretenberg_rs::generated_types::Response::ChonkComputeIvcVkResponse(r) => Ok(r.bytes),
+            _ => Err("Unexpected response".to_string()),
+        }
+    })();
+
+    match res {
+        Ok(v) => ok(v),
+        Err(e) => err(e),
+    }
+}
+
+#[no_mangle]
+pub extern "C" fn bb_verify_client_ivc(
+    proof_ptr: *const u8,
+    proof_len: usize,
+    vk_ptr: *const u8,
+    vk_len: usize,
+) -> bool {
+    let res: Result<bool, String> = (|| {
+        if proof_ptr.is_null() || vk_ptr.is_null() {
+            return Err("null pointer".into());
+        }
+        let proof_bytes = unsafe { std::slice::from_raw_parts(proof_ptr, proof_len) };
+        let vk_bytes = unsafe { std::slice::from_raw_parts(vk_ptr, vk_len) }.to_vec();
+
+        let proof = rmp_serde::from_slice(proof_bytes)
+            .map_err(|e| format!("{}failed to deserialize proof: {}", INVALID_PROOF, e))?;
+        match call_bb(Command::ChonkVerify(barretenberg_rs::generated_types::ChonkVerify::new(proof, vk_bytes)))? {
+            barretenberg_rs::generated_types::Response::ChonkVerifyResponse(r) => Ok(r.valid),
+            _ => Err("Unexpected response".to_string()),
+        }
     })();
 
     res.unwrap_or(false)
@@ -0,0 +1,67 @@
+package barretenberg
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrVerifierMemoryLimitExceeded is returned when the estimated memory for verifying a proof
+// exceeds the limit set by SetVerifierMemoryLimit.
+var ErrVerifierMemoryLimitExceeded = errors.New("verifier memory limit exceeded")
+
+// vecOverhead is the size of the header the native shim allocates for each decoded field element.
+const vecOverhead = 24
+
+// batchedRelationLength is the number of evaluations of each sumcheck round univariate.
+const batchedRelationLength = 9
+
+// verifierRoundSize is the memory the verifier holds per round of sumcheck and Gemini folding:
+// the round univariate, the fold evaluation and the fold commitment.
+const verifierRoundSize = (batchedRelationLength+1)*(fieldSize+vecOverhead) + g1PointSize
+
+var verifierMemoryLimit atomic.Uint64
+
+// SetVerifierMemoryLimit sets a limit on the inputs accepted for verification, expressed in bytes
+// of estimated verifier memory. Inputs exceeding it are rejected before reaching the native layer.
+// This is an input-size limit: the estimate is derived from the proof and verification key, from
+// their sizes and the number of rounds implied by the key's log circuit size, and does not bound
+// what the native verifier actually allocates. A limit of 0 disables the check, which is the default.
+func SetVerifierMemoryLimit(bytes uint64) {
+	verifierMemoryLimit.Store(bytes)
+}
+
+// CheckVerifierMemoryLimit reports whether verifying proof against vk stays within the limit
+// set by SetVerifierMemoryLimit, returning an error wrapping ErrVerifierMemoryLimitExceeded if not.
+// VerifyUltraHonk performs this check before every verification.
+func CheckVerifierMemoryLimit(proof []byte, vk []byte) error {
+	limit := verifierMemoryLimit.Load()
+	if limit == 0 {
+		return nil
+	}
+	// The inputs alone must fit before the proof is decoded to estimate the rest.
+	if size := uint64(len(proof)) + uint64(len(vk)); size > limit {
+		return fmt.Errorf("%w: inputs are %d bytes, limit is %d", ErrVerifierMemoryLimitExceeded, size, limit)
+	}
+	need := verifierMemoryEstimate(proof, vk)
+	if need > limit {
+		return fmt.Errorf("%w: needs about %d bytes, limit is %d", ErrVerifierMemoryLimitExceeded, need, limit)
+	}
+	return nil
+}
+
+// verifierMemoryEstimate estimates the memory the native verifier allocates for its inputs:
+// the copied verification key, every decoded proof element and the state of each round.
+func verifierMemoryEstimate(proof []byte, vk []byte) uint64 {
+	p, err := decodeProof(proof)
+	if err != nil {
+		// Undecodable proofs are rejected by the verifier before any element is allocated.
+		return uint64(len(proof)) + uint64(len(vk))
+	}
+	elements := uint64(len(p.publicInputs) + len(p.proof))
+	need := uint64(len(vk)) + elements*(fieldSize+vecOverhead)
+	if h, err := parseVKHeader(vk); err == nil {
+		need += h.logCircuitSize * verifierRoundSize
+	}
+	return need
+}
@@ -0,0 +1,75 @@
+package barretenberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifierMemoryLimit(t *testing.T) {
+	defer SetVerifierMemoryLimit(0)
+
+	p := &proofResponse{
+		publicInputs: [][32]byte{testField(9)},
+		proof:        make([][32]byte, 100),
+	}
+	proof := p.encode()
+	vk := make([]byte, 64)
+
+	if err := CheckVerifierMemoryLimit(proof, vk); err != nil {
+		t.Fatalf("unexpected error with no limit: %v", err)
+	}
+
+	SetVerifierMemoryLimit(1024)
+	if err := CheckVerifierMemoryLimit(proof, vk); !errors.Is(err, ErrVerifierMemoryLimitExceeded) {
+		t.Fatalf("expected ErrVerifierMemoryLimitExceeded, got %v", err)
+	}
+	if VerifyUltraHonk(proof, vk, DefaultSettings()) {
+		t.Fatalf("expected verification to be rejected by the memory limit")
+	}
+
+	SetVerifierMemoryLimit(1 << 20)
+	if err := CheckVerifierMemoryLimit(proof, vk); err != nil {
+		t.Fatalf("unexpected error below the limit: %v", err)
+	}
+}
+
+func TestVerifierMemoryLimitLogCircuitSize(t *testing.T) {
+	defer SetVerifierMemoryLimit(0)
+
+	p := testProof([][32]byte{testField(9)}, false)
+	proof := p.encode()
+	small := testVK(1, 1+pairingPointsSize)
+	large := testVK(maxLogCircuitSize, 1+pairingPointsSize)
+	if len(small) != len(large) {
+		t.Fatalf("test keys differ in size")
+	}
+
+	// Same input sizes, but the large circuit's rounds don't fit.
+	SetVerifierMemoryLimit(verifierMemoryEstimate(proof, small) + verifierRoundSize)
+	if err := CheckVerifierMemoryLimit(proof, small); err != nil {
+		t.Fatalf("unexpected error for the small circuit: %v", err)
+	}
+	if err := CheckVerifierMemoryLimit(proof, large); !errors.Is(err, ErrVerifierMemoryLimitExceeded) {
+		t.Fatalf("expected ErrVerifierMemoryLimitExceeded for the large circuit, got %v", err)
+	}
+}
+
+func TestVerifierMemoryLimitCraftedProof(t *testing.T) {
+	defer SetVerifierMemoryLimit(0)
+
+	// A proof claiming far more elements than its bytes can hold.
+	const n = 1 << 16
+	b := appendMapHeader(nil, 2)
+	b = appendString(b, "public_inputs")
+	b = appendArrayHeader(b, n)
+	b = append(b, make([]byte, n)...)
+	if _, err := decodeProof(b); !errors.Is(err, errMsgpackShort) {
+		t.Fatalf("expected the element count to be rejected, got %v", err)
+	}
+
+	// Inputs larger than the limit are rejected before they are decoded.
+	SetVerifierMemoryLimit(uint64(len(b)) - 1)
+	if err := CheckVerifierMemoryLimit(b, nil); !errors.Is(err, ErrVerifierMemoryLimitExceeded) {
+		t.Fatalf("expected ErrVerifierMemoryLimitExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package barretenberg
+
+/*
+#include "libnoir_ffi/barretenberg_ffi.h"
+
+extern void goLogCallback(int32_t level, char *msg);
+*/
+import "C"
+import (
+	"fmt"
+	"sync"
+)
+
+// LogLevel is the severity of a backend log line.
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	case LogError:
+		return "error"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+var logHandler struct {
+	mu sync.RWMutex
+	fn func(level LogLevel, msg string)
+}
+
+// SetLogHandler registers fn to receive backend log lines, including proving milestones such as
+// "constructing proof" that are reported as LogInfo, e.g. to drive a progress indicator.
+// A failed backend command is reported as LogError with its error message.
+// With BackendPipe, the stderr lines of the `bb` subprocess are forwarded too, as LogError or
+// LogWarn when bb marks them as an error or warning and as LogInfo otherwise. The native
+// backend writes its own output straight to the process stderr, which is not forwarded.
+// fn may be called from any goroutine, concurrently, while a backend call is in progress, so it
+// must not call back into this package. Pass nil to discard log output, which is the default.
+func SetLogHandler(fn func(level LogLevel, msg string)) {
+	logHandler.mu.Lock()
+	defer logHandler.mu.Unlock()
+	logHandler.fn = fn
+	if fn != nil {
+		C.bb_set_log_callback(C.BBLogCallback(C.goLogCallback))
+	} else {
+		C.bb_set_log_callback(nil)
+	}
+}
+
+// emitLog passes a log line to the registered handler, if any.
+func emitLog(level LogLevel, msg string) {
+	logHandler.mu.RLock()
+	defer logHandler.mu.RUnlock()
+	if logHandler.fn != nil {
+		logHandler.fn(level, msg)
+	}
+}
+
+//export goLogCallback
+func goLogCallback(level C.int32_t, msg *C.char) {
+	emitLog(LogLevel(level), C.GoString(msg))
+}
@@ -0,0 +1,309 @@
+package barretenberg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// This file implements the small subset of MessagePack needed to read and write the proof
+// responses produced by the native shim, without pulling in a third-party dependency.
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+type msgpackReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *msgpackReader) remaining() int {
+	return len(r.buf) - r.pos
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || r.remaining() < n {
+		return nil, errMsgpackShort
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgpackReader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// length reads an n-byte big-endian length.
+func (r *msgpackReader) length(n int) (int, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+func (r *msgpackReader) readMapLen() (int, error) {
+	t, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case t >= 0x80 && t <= 0x8f:
+		return int(t & 0x0f), nil
+	case t == 0xde:
+		return r.length(2)
+	case t == 0xdf:
+		return r.length(4)
+	}
+	return 0, fmt.Errorf("msgpack: expected map, got type 0x%02x", t)
+}
+
+func (r *msgpackReader) readArrayLen() (int, error) {
+	t, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case t >= 0x90 && t <= 0x9f:
+		return int(t & 0x0f), nil
+	case t == 0xdc:
+		return r.length(2)
+	case t == 0xdd:
+		return r.length(4)
+	}
+	return 0, fmt.Errorf("msgpack: expected array, got type 0x%02x", t)
+}
+
+func (r *msgpackReader) readString() (string, error) {
+	t, err := r.byte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch {
+	case t >= 0xa0 && t <= 0xbf:
+		n = int(t & 0x1f)
+	case t == 0xd9:
+		n, err = r.length(1)
+	case t == 0xda:
+		n, err = r.length(2)
+	case t == 0xdb:
+		n, err = r.length(4)
+	default:
+		return "", fmt.Errorf("msgpack: expected string, got type 0x%02x", t)
+	}
+	if err != nil {
+		return "", err
+	}
+	b, err := r.next(n)
+	return string(b), err
+}
+
+func (r *msgpackReader) readUint() (uint64, error) {
+	t, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	if t <= 0x7f {
+		return uint64(t), nil
+	}
+	var n int
+	switch t {
+	case 0xcc:
+		n = 1
+	case 0xcd:
+		n = 2
+	case 0xce:
+		n = 4
+	case 0xcf:
+		n = 8
+	default:
+		return 0, fmt.Errorf("msgpack: expected unsigned integer, got type 0x%02x", t)
+	}
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// readBytes reads a byte string, which serde may encode either as bin or as an array of integers.
+func (r *msgpackReader) readBytes() ([]byte, error) {
+	t, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch t {
+	case 0xc4:
+		n, err = r.length(1)
+	case 0xc5:
+		n, err = r.length(2)
+	case 0xc6:
+		n, err = r.length(4)
+	default:
+		r.pos--
+		n, err := r.readArrayLen()
+		if err != nil {
+			return nil, fmt.Errorf("msgpack: expected bytes, got type 0x%02x", t)
+		}
+		if n > r.remaining() {
+			return nil, errMsgpackShort
+		}
+		out := make([]byte, n)
+		for i := range out {
+			v, err := r.readUint()
+			if err != nil {
+				return nil, err
+			}
+			if v > 0xff {
+				return nil, fmt.Errorf("msgpack: byte value %d out of range", v)
+			}
+			out[i] = byte(v)
+		}
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.next(n)
+}
+
+// skip advances past the next value of any type.
+func (r *msgpackReader) skip() error {
+	t, err := r.byte()
+	if err != nil {
+		return err
+	}
+	var n, items int
+	switch {
+	case t <= 0x7f, t >= 0xe0, t == 0xc0, t == 0xc2, t == 0xc3:
+		return nil
+	case t >= 0x80 && t <= 0x8f:
+		items = 2 * int(t&0x0f)
+	case t >= 0x90 && t <= 0x9f:
+		items = int(t & 0x0f)
+	case t >= 0xa0 && t <= 0xbf:
+		n = int(t & 0x1f)
+	case t == 0xc4 || t == 0xd9:
+		n, err = r.length(1)
+	case t == 0xc5 || t == 0xda:
+		n, err = r.length(2)
+	case t == 0xc6 || t == 0xdb:
+		n, err = r.length(4)
+	case t == 0xcc || t == 0xd0:
+		n = 1
+	case t == 0xcd || t == 0xd1:
+		n = 2
+	case t == 0xce || t == 0xd2 || t == 0xca:
+		n = 4
+	case t == 0xcf || t == 0xd3 || t == 0xcb:
+		n = 8
+	case t == 0xd4:
+		n = 2
+	case t == 0xd5:
+		n = 3
+	case t == 0xd6:
+		n = 5
+	case t == 0xd7:
+		n = 9
+	case t == 0xd8:
+		n = 17
+	case t == 0xc7:
+		n, err = r.length(1)
+		n++
+	case t == 0xc8:
+		n, err = r.length(2)
+		n++
+	case t == 0xc9:
+		n, err = r.length(4)
+		n++
+	case t == 0xdc:
+		items, err = r.length(2)
+	case t == 0xdd:
+		items, err = r.length(4)
+	case t == 0xde:
+		items, err = r.length(2)
+		items *= 2
+	case t == 0xdf:
+		items, err = r.length(4)
+		items *= 2
+	default:
+		return fmt.Errorf("msgpack: unsupported type 0x%02x", t)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := r.next(n); err != nil {
+		return err
+	}
+	for i := 0; i < items; i++ {
+		if err := r.skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 0x0f:
+		return append(b, 0x80|byte(n))
+	case n <= 0xffff:
+		return append(b, 0xde, byte(n>>8), byte(n))
+	}
+	return append(b, 0xdf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 0x0f:
+		return append(b, 0x90|byte(n))
+	case n <= 0xffff:
+		return append(b, 0xdc, byte(n>>8), byte(n))
+	}
+	return append(b, 0xdd, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n <= 0x1f:
+		b = append(b, 0xa0|byte(n))
+	case n <= 0xff:
+		b = append(b, 0xd9, byte(n))
+	case n <= 0xffff:
+		b = append(b, 0xda, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xdb, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(b, s...)
+}
+
+// appendBytes writes v as an array of integers, the encoding serde uses for a plain Vec<u8>.
+func appendBytes(b []byte, v []byte) []byte {
+	b = appendArrayHeader(b, len(v))
+	for _, c := range v {
+		if c <= 0x7f {
+			b = append(b, c)
+		} else {
+			b = append(b, 0xcc, c)
+		}
+	}
+	return b
+}
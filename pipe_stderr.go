@@ -0,0 +1,64 @@
+package barretenberg
+
+/*
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+var pipeStderr struct {
+	mu     sync.Mutex
+	w      io.Writer
+	writer *os.File // write end handed to the native layer, kept open for its lifetime
+}
+
+func init() {
+	if GetBackendType() == BackendPipe {
+		capturePipeStderr()
+	}
+}
+
+// SetPipeStderr forwards the stderr of the `bb` subprocess used by BackendPipe to w, one line at a time.
+// Lines are dropped rather than holding up `bb` if w falls behind. Pass nil to stop forwarding.
+// Independently of w, the last lines the subprocess writes while running a command are attached
+// to the error of that command, see BackendError.Stderr.
+func SetPipeStderr(w io.Writer) {
+	pipeStderr.mu.Lock()
+	pipeStderr.w = w
+	pipeStderr.mu.Unlock()
+	capturePipeStderr()
+}
+
+// capturePipeStderr makes the native layer copy the stderr lines of `bb` to a pipe read here.
+func capturePipeStderr() {
+	pipeStderr.mu.Lock()
+	defer pipeStderr.mu.Unlock()
+	if pipeStderr.writer != nil {
+		return
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	pipeStderr.writer = w
+	C.bb_set_pipe_stderr_fd(C.int32_t(w.Fd()))
+
+	go func() {
+		defer r.Close()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			// Write outside the lock, so a slow writer doesn't hold up SetPipeStderr.
+			pipeStderr.mu.Lock()
+			w := pipeStderr.w
+			pipeStderr.mu.Unlock()
+			if w != nil {
+				io.WriteString(w, scanner.Text()+"\n")
+			}
+		}
+	}()
+}
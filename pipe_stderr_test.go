@@ -0,0 +1,34 @@
+package barretenberg
+
+import (
+	"testing"
+	"time"
+)
+
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestPipeStderr(t *testing.T) {
+	lines := make(lineWriter, 16)
+	SetPipeStderr(lines)
+	defer SetPipeStderr(nil)
+
+	// Write as the native layer would.
+	if _, err := pipeStderr.writer.WriteString("first\nsecond\n"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"first\n", "second\n"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Fatalf("got %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
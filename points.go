@@ -0,0 +1,123 @@
+package barretenberg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Compressed G1 points are 32 bytes: the big-endian x coordinate, whose two top bits are free
+// since x < 2^254, with those bits used as flags, as gnark-crypto does:
+//   - 0b10: y is the smaller of the two roots, i.e. y <= (p-1)/2;
+//   - 0b11: y is the larger root;
+//   - 0b01: point at infinity, with all other bits zero.
+const (
+	g1CompressedSize   = 32
+	compressedSmallest = 0b10 << 6
+	compressedLargest  = 0b11 << 6
+	compressedInfinity = 0b01 << 6
+	compressedFlagMask = 0b11 << 6
+)
+
+// g1Infinity is the backend's serialization of the G1 point at infinity: every bit set.
+var g1Infinity = bytes.Repeat([]byte{0xff}, g1PointSize)
+
+// fqHalf is (p-1)/2, the largest "smaller" root.
+var fqHalf = new(big.Int).Rsh(fqModulus, 1)
+
+// CompressPoints returns a verification key with its G1 points compressed to 32 bytes each.
+// The header is kept as is. Every point is checked to be on the curve.
+// Only the verification key layout, a header followed by G1 points, is supported. Proofs are
+// not, so their commitments stay uncompressed: they are interleaved with scalars at positions
+// that depend on the circuit size and settings, so there is no point section to convert.
+func CompressPoints(data []byte) ([]byte, error) {
+	if _, err := parseVKHeader(data); err != nil {
+		return nil, err
+	}
+	points := data[vkHeaderSize:]
+	if len(points)%g1PointSize != 0 {
+		return nil, fmt.Errorf("invalid verification key: %d point bytes is not a multiple of %d", len(points), g1PointSize)
+	}
+	out := append(make([]byte, 0, vkHeaderSize+len(points)/2), data[:vkHeaderSize]...)
+	for i := 0; i < len(points); i += g1PointSize {
+		p := points[i : i+g1PointSize]
+		var c [g1CompressedSize]byte
+		if bytes.Equal(p, g1Infinity) {
+			c[0] = compressedInfinity
+			out = append(out, c[:]...)
+			continue
+		}
+		x := new(big.Int).SetBytes(p[:fieldSize])
+		y := new(big.Int).SetBytes(p[fieldSize:])
+		if !isOnCurveG1(x, y) {
+			return nil, fmt.Errorf("verification key point %d is not on the curve", i/g1PointSize)
+		}
+		x.FillBytes(c[:])
+		if y.Cmp(fqHalf) > 0 {
+			c[0] |= compressedLargest
+		} else {
+			c[0] |= compressedSmallest
+		}
+		out = append(out, c[:]...)
+	}
+	return out, nil
+}
+
+// DecompressPoints reverses CompressPoints, returning the verification key with uncompressed
+// G1 points as accepted by the backend. Like CompressPoints, it only accepts verification keys.
+func DecompressPoints(data []byte) ([]byte, error) {
+	if _, err := parseVKHeader(data); err != nil {
+		return nil, err
+	}
+	points := data[vkHeaderSize:]
+	if len(points)%g1CompressedSize != 0 {
+		return nil, fmt.Errorf("invalid compressed verification key: %d point bytes is not a multiple of %d", len(points), g1CompressedSize)
+	}
+	out := append(make([]byte, 0, vkHeaderSize+2*len(points)), data[:vkHeaderSize]...)
+	for i := 0; i < len(points); i += g1CompressedSize {
+		p, err := decompressG1(points[i : i+g1CompressedSize])
+		if err != nil {
+			return nil, fmt.Errorf("verification key point %d: %w", i/g1CompressedSize, err)
+		}
+		out = append(out, p...)
+	}
+	return out, nil
+}
+
+// decompressG1 decodes a single compressed point into its 64-byte uncompressed form.
+func decompressG1(c []byte) ([]byte, error) {
+	flags := c[0] & compressedFlagMask
+	if flags == compressedInfinity {
+		if c[0] != compressedInfinity || !bytes.Equal(c[1:], make([]byte, g1CompressedSize-1)) {
+			return nil, errors.New("invalid encoding of the point at infinity")
+		}
+		return append([]byte(nil), g1Infinity...), nil
+	}
+	if flags != compressedSmallest && flags != compressedLargest {
+		return nil, errors.New("point is not compressed")
+	}
+
+	xb := append([]byte(nil), c...)
+	xb[0] &^= compressedFlagMask
+	x := new(big.Int).SetBytes(xb)
+	if x.Cmp(fqModulus) >= 0 {
+		return nil, errors.New("x coordinate is not reduced")
+	}
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, big.NewInt(3))
+	rhs.Mod(rhs, fqModulus)
+	y := new(big.Int).ModSqrt(rhs, fqModulus)
+	if y == nil {
+		return nil, errors.New("x coordinate is not on the curve")
+	}
+	if (y.Cmp(fqHalf) > 0) != (flags == compressedLargest) {
+		y.Sub(fqModulus, y)
+	}
+
+	out := make([]byte, g1PointSize)
+	x.FillBytes(out[:fieldSize])
+	y.FillBytes(out[fieldSize:])
+	return out, nil
+}
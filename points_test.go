@@ -0,0 +1,47 @@
+package barretenberg
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestCompressPoints(t *testing.T) {
+	// The generator (1, 2), its negation (1, p-2) and the point at infinity.
+	vk := testVK(5, pairingPointsSize)[:vkHeaderSize]
+	for _, y := range []*big.Int{big.NewInt(2), new(big.Int).Sub(fqModulus, big.NewInt(2))} {
+		var p [g1PointSize]byte
+		p[fieldSize-1] = 1
+		y.FillBytes(p[fieldSize:])
+		vk = append(vk, p[:]...)
+	}
+	vk = append(vk, g1Infinity...)
+
+	compressed, err := CompressPoints(vk)
+	if err != nil {
+		t.Fatalf("failed to compress points: %v", err)
+	}
+	if len(compressed) != vkHeaderSize+3*g1CompressedSize {
+		t.Fatalf("unexpected compressed size %d", len(compressed))
+	}
+	if compressed[vkHeaderSize]&compressedFlagMask == compressed[vkHeaderSize+g1CompressedSize]&compressedFlagMask {
+		t.Fatalf("a point and its negation have the same sign flag")
+	}
+	decompressed, err := DecompressPoints(compressed)
+	if err != nil {
+		t.Fatalf("failed to decompress points: %v", err)
+	}
+	if !bytes.Equal(decompressed, vk) {
+		t.Fatalf("points did not round-trip")
+	}
+
+	// (1, 3) is not on the curve.
+	bad := append([]byte(nil), vk...)
+	bad[vkHeaderSize+g1PointSize-1] = 3
+	if _, err := CompressPoints(bad); err == nil {
+		t.Fatalf("expected error for a point not on the curve")
+	}
+	if _, err := DecompressPoints(vk); err == nil {
+		t.Fatalf("expected error decompressing uncompressed points")
+	}
+}
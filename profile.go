@@ -0,0 +1,72 @@
+package barretenberg
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	profileMu  sync.Mutex
+	profileOut io.Writer
+)
+
+// SetProfileOutput enables per-phase timing output for proving operations.
+// Each phase is written to w once it completes as a tab-separated line "op\tphase\tduration",
+// followed by a "total" line per operation. Pass nil to disable profiling.
+// The phases are coarse wall-clock spans measured on the Go side around the steps it controls:
+// input encoding, the native call and the result copy. The native backend does not expose its
+// internal profiling hooks through the FFI, so witness generation, circuit construction,
+// commitments and sumcheck are all part of the single native_prove span.
+func SetProfileOutput(w io.Writer) {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	profileOut = w
+}
+
+// phaseTimer records the duration of consecutive phases of a single operation.
+// A nil *phaseTimer is valid and records nothing, so callers don't need to check if profiling is enabled.
+type phaseTimer struct {
+	op    string
+	start time.Time
+	last  time.Time
+}
+
+// startProfile returns a timer for op, or nil if profiling is disabled.
+func startProfile(op string) *phaseTimer {
+	profileMu.Lock()
+	enabled := profileOut != nil
+	profileMu.Unlock()
+	if !enabled {
+		return nil
+	}
+	now := time.Now()
+	return &phaseTimer{op: op, start: now, last: now}
+}
+
+// mark ends the current phase, naming it phase, and starts the next one.
+func (p *phaseTimer) mark(phase string) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.write(fmt.Sprintf("%s\t%s\t%s\n", p.op, phase, now.Sub(p.last)))
+	p.last = now
+}
+
+// done writes the total duration of the operation.
+func (p *phaseTimer) done() {
+	if p == nil {
+		return
+	}
+	p.write(fmt.Sprintf("%s\ttotal\t%s\n", p.op, time.Since(p.start)))
+}
+
+func (p *phaseTimer) write(line string) {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	if profileOut != nil {
+		io.WriteString(profileOut, line)
+	}
+}
@@ -0,0 +1,35 @@
+package barretenberg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProfileOutput(t *testing.T) {
+	var buf bytes.Buffer
+	SetProfileOutput(&buf)
+	defer SetProfileOutput(nil)
+
+	prof := startProfile("prove_ultrahonk")
+	prof.mark("encode_inputs")
+	prof.mark("native_prove")
+	prof.done()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{"encode_inputs", "native_prove", "total"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || fields[0] != "prove_ultrahonk" || fields[1] != want[i] {
+			t.Errorf("line %d = %q, want prove_ultrahonk\\t%s\\t<duration>", i, line, want[i])
+		}
+	}
+
+	SetProfileOutput(nil)
+	if prof := startProfile("prove_ultrahonk"); prof != nil {
+		t.Fatalf("startProfile returned a timer with profiling disabled")
+	}
+}
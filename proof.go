@@ -0,0 +1,314 @@
+package barretenberg
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// fieldSize is the size in bytes of a serialized bn254 scalar field element.
+const fieldSize = 32
+
+// Sizes, in field elements, of the public inputs the backend appends to every circuit.
+// They are split off from the circuit's own public inputs and lead the proof elements.
+const (
+	pairingPointsSize = 16 // pairing point accumulator, present in every UltraHonk proof
+	ipaClaimSize      = 10 // IPA claim, present when IpaAccumulation is enabled
+)
+
+// proofResponse is the decoded form of the proof returned by ProveUltraHonk, which is the
+// msgpack-encoded CircuitProveResponse of the backend: a map holding the public inputs and
+// the remaining proof elements as separate lists of field elements.
+type proofResponse struct {
+	publicInputs [][fieldSize]byte
+	proof        [][fieldSize]byte
+	// extra holds any other fields of the response verbatim, so they survive re-encoding.
+	extra []msgpackEntry
+}
+
+type msgpackEntry struct {
+	key   string
+	value []byte
+}
+
+// decodeProof parses a proof as returned by ProveUltraHonk.
+func decodeProof(data []byte) (*proofResponse, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty proof")
+	}
+	r := &msgpackReader{buf: data}
+	n, err := r.readMapLen()
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof encoding: %w", err)
+	}
+	p := &proofResponse{}
+	var havePublicInputs, haveProof bool
+	for i := 0; i < n; i++ {
+		key, err := r.readString()
+		if err != nil {
+			return nil, fmt.Errorf("invalid proof encoding: %w", err)
+		}
+		switch key {
+		case "public_inputs":
+			p.publicInputs, err = readFields(r)
+			havePublicInputs = true
+		case "proof":
+			p.proof, err = readFields(r)
+			haveProof = true
+		default:
+			start := r.pos
+			if err = r.skip(); err == nil {
+				p.extra = append(p.extra, msgpackEntry{key: key, value: data[start:r.pos]})
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid proof field %q: %w", key, err)
+		}
+	}
+	if r.remaining() != 0 {
+		return nil, fmt.Errorf("invalid proof encoding: %d trailing bytes", r.remaining())
+	}
+	if !havePublicInputs || !haveProof {
+		return nil, errors.New("invalid proof encoding: missing public_inputs or proof")
+	}
+	return p, nil
+}
+
+func readFields(r *msgpackReader) ([][fieldSize]byte, error) {
+	n, err := r.readArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	// Every element takes at least a header byte and its 32 bytes, which bounds the allocation
+	// below by the size of the input.
+	if n > r.remaining()/(fieldSize+1) {
+		return nil, errMsgpackShort
+	}
+	fields := make([][fieldSize]byte, n)
+	for i := range fields {
+		b, err := r.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != fieldSize {
+			return nil, fmt.Errorf("element %d has %d bytes, expected %d", i, len(b), fieldSize)
+		}
+		copy(fields[i][:], b)
+	}
+	return fields, nil
+}
+
+// encode serializes the response back into the format accepted by VerifyUltraHonk.
+func (p *proofResponse) encode() []byte {
+	b := appendMapHeader(nil, 2+len(p.extra))
+	b = appendString(b, "public_inputs")
+	b = appendFields(b, p.publicInputs)
+	b = appendString(b, "proof")
+	b = appendFields(b, p.proof)
+	for _, e := range p.extra {
+		b = appendString(b, e.key)
+		b = append(b, e.value...)
+	}
+	return b
+}
+
+func appendFields(b []byte, fields [][fieldSize]byte) []byte {
+	b = appendArrayHeader(b, len(fields))
+	for i := range fields {
+		b = appendBytes(b, fields[i][:])
+	}
+	return b
+}
+
+// splitFields splits a flat buffer of concatenated field elements.
+func splitFields(data []byte) ([][fieldSize]byte, error) {
+	if len(data)%fieldSize != 0 {
+		return nil, fmt.Errorf("length %d is not a multiple of %d", len(data), fieldSize)
+	}
+	fields := make([][fieldSize]byte, len(data)/fieldSize)
+	for i := range fields {
+		copy(fields[i][:], data[i*fieldSize:])
+	}
+	return fields, nil
+}
+
+// NormalizeWASMProof converts a proof produced by barretenberg.wasm (bb.js) into the format
+// accepted by VerifyUltraHonk.
+// bb.js returns the proof as the flat concatenation of its 32-byte field elements, with the
+// public inputs returned separately, while the native backend wraps both into a single
+// msgpack-encoded response. Pass the public inputs from bb.js in the same order.
+// A proof that is already in the native format is returned unchanged.
+func NormalizeWASMProof(proof []byte, publicInputs ...[32]byte) ([]byte, error) {
+	if _, err := decodeProof(proof); err == nil {
+		if len(publicInputs) > 0 {
+			return nil, errors.New("proof is already in native format and embeds its public inputs")
+		}
+		return proof, nil
+	}
+	if len(proof) == 0 {
+		return nil, errors.New("empty proof")
+	}
+	fields, err := splitFields(proof)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WASM proof: %w", err)
+	}
+	p := &proofResponse{
+		publicInputs: publicInputs,
+		proof:        fields,
+	}
+	if p.publicInputs == nil {
+		p.publicInputs = [][fieldSize]byte{}
+	}
+	return p.encode(), nil
+}
+
+// ExtractPairingPoints returns the pairing point accumulator carried by a proof, in the order a
+// recursive verifier circuit exposes them as public inputs.
+// The accumulator leads the proof elements, followed by the IPA claim when settings.IpaAccumulation is set.
+func ExtractPairingPoints(proof []byte, settings ProofSystemSettings) ([]Fr, error) {
+	p, err := decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	return p.pairingPoints(settings)
+}
+
+// pairingPoints returns the pairing point accumulator of a decoded proof, checking that the
+// proof is long enough for it and for the IPA claim when settings.IpaAccumulation is set.
+func (p *proofResponse) pairingPoints(settings ProofSystemSettings) ([]Fr, error) {
+	need := pairingPointsSize
+	if settings.IpaAccumulation {
+		need += ipaClaimSize
+	}
+	if len(p.proof) < need {
+		return nil, fmt.Errorf("proof has %d elements, too short to hold the pairing points", len(p.proof))
+	}
+	points := make([]Fr, pairingPointsSize)
+	for i := range points {
+		points[i] = Fr(p.proof[i])
+	}
+	return points, nil
+}
+
+// ProofMeta describes a proof, as read by InspectProof.
+type ProofMeta struct {
+	ByteLen         int    // size of the serialized proof
+	NumPublicInputs uint32 // public inputs of the circuit, excluding the pairing points and IPA claim
+	HasIpaClaim     bool   // whether the proof carries an IPA claim, i.e. was produced with IpaAccumulation
+	Flavor          string // best-effort Barretenberg flavor: "UltraFlavor" or "UltraRollupFlavor"
+}
+
+// limbBits is the size of the limbs barretenberg splits non-native field elements into
+// when it exposes them as public inputs.
+const limbBits = 68
+
+// InspectProof reads the metadata of a proof returned by ProveUltraHonk without verifying it,
+// so it doesn't need the backend or an SRS.
+// The proof doesn't record its settings: the IPA claim is detected from its layout, a claim's
+// commitment being a Grumpkin point after the limbs of its opening pair, and the oracle hash
+// and ZK mode can't be told. Flavor is therefore UltraRollupFlavor for proofs with an IPA claim
+// and UltraFlavor otherwise, whatever the oracle hash.
+func InspectProof(proof []byte) (ProofMeta, error) {
+	var meta ProofMeta
+	p, err := decodeProof(proof)
+	if err != nil {
+		return meta, err
+	}
+	if len(p.proof) < pairingPointsSize {
+		return meta, fmt.Errorf("proof has %d elements, too short to hold the pairing points", len(p.proof))
+	}
+	meta.ByteLen = len(proof)
+	meta.NumPublicInputs = uint32(len(p.publicInputs))
+	meta.HasIpaClaim = hasIpaClaim(p.proof[pairingPointsSize:])
+	meta.Flavor = "UltraFlavor"
+	if meta.HasIpaClaim {
+		meta.Flavor = "UltraRollupFlavor"
+	}
+	return meta, nil
+}
+
+// hasIpaClaim reports whether fields start with an IPA claim: the opening challenge and evaluation
+// as 4 limbs each, followed by the commitment's native coordinates.
+func hasIpaClaim(fields [][fieldSize]byte) bool {
+	if len(fields) < ipaClaimSize {
+		return false
+	}
+	for _, f := range fields[:ipaClaimSize-2] {
+		if new(big.Int).SetBytes(f[:]).BitLen() > limbBits {
+			return false
+		}
+	}
+	x := new(big.Int).SetBytes(fields[ipaClaimSize-2][:])
+	y := new(big.Int).SetBytes(fields[ipaClaimSize-1][:])
+	return isOnCurveGrumpkin(x, y)
+}
+
+// PublicInputs returns the circuit's public inputs carried by a proof, as 0x-prefixed hex field
+// elements in circuit order: the public parameters followed by the return value.
+// Proofs returned by ProveUltraHonk hold their public inputs in a separate list, so their
+// position doesn't depend on the oracle hash or ZK settings. settings is checked against the
+// proof layout: the proof must be long enough for the pairing points, and the IPA claim when
+// settings.IpaAccumulation is set, that the backend adds after the circuit's public inputs.
+func PublicInputs(proof []byte, settings ProofSystemSettings) ([]string, error) {
+	p, err := decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.pairingPoints(settings); err != nil {
+		return nil, err
+	}
+	inputs := make([]string, len(p.publicInputs))
+	for i := range p.publicInputs {
+		inputs[i] = Fr(p.publicInputs[i]).String()
+	}
+	return inputs, nil
+}
+
+// ProofAsFields returns the proof elements as 0x-prefixed hex field elements, in the layout a
+// recursive verifier circuit takes as its proof argument: the pairing point accumulator, the IPA
+// claim and IPA proof when settings.IpaAccumulation was set, then the UltraHonk proof itself.
+// The circuit's public inputs are not included, as recursive verifiers take them separately.
+func ProofAsFields(proof []byte) ([]string, error) {
+	p, err := decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, len(p.proof))
+	for i := range p.proof {
+		fields[i] = Fr(p.proof[i]).String()
+	}
+	return fields, nil
+}
+
+// CanonicalProof re-serializes a proof into the canonical byte layout, so semantically identical
+// proofs produce identical bytes regardless of the tool that encoded them.
+// The canonical layout uses minimal msgpack headers, encodes byte strings the way the native
+// shim does and orders fields by key, with public_inputs and proof first. The proof is checked
+// against vk: its public inputs must match the key and every element must be a reduced field element.
+func CanonicalProof(proof []byte, vk []byte) ([]byte, error) {
+	p, err := decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	h, err := parseVKHeader(vk)
+	if err != nil {
+		return nil, err
+	}
+	extra := h.numPublicInputs - uint64(len(p.publicInputs))
+	if uint64(len(p.publicInputs)) > h.numPublicInputs || (extra != pairingPointsSize && extra != pairingPointsSize+ipaClaimSize) {
+		return nil, fmt.Errorf("proof has %d public inputs, not consistent with the %d of the verification key", len(p.publicInputs), h.numPublicInputs)
+	}
+	if len(p.proof) < int(extra) {
+		return nil, fmt.Errorf("proof has %d elements, too short for the verification key", len(p.proof))
+	}
+	for _, fields := range [][][fieldSize]byte{p.publicInputs, p.proof} {
+		for i := range fields {
+			if new(big.Int).SetBytes(fields[i][:]).Cmp(frModulus) >= 0 {
+				return nil, fmt.Errorf("proof element %d is not a reduced field element", i)
+			}
+		}
+	}
+	sort.SliceStable(p.extra, func(i, j int) bool { return p.extra[i].key < p.extra[j].key })
+	return p.encode(), nil
+}
@@ -0,0 +1,296 @@
+package barretenberg
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+)
+
+func testField(v byte) [32]byte {
+	var f [32]byte
+	f[31] = v
+	return f
+}
+
+// testProof returns a proof with the given public inputs whose elements are the pairing points,
+// the IPA claim if ipa is set, and 4 more elements, with element i set to testField(i).
+func testProof(publicInputs [][32]byte, ipa bool) *proofResponse {
+	n := pairingPointsSize + 4
+	if ipa {
+		n += ipaClaimSize
+	}
+	p := &proofResponse{publicInputs: publicInputs}
+	for i := 0; i < n; i++ {
+		p.proof = append(p.proof, testField(byte(i)))
+	}
+	return p
+}
+
+func TestProofEncodingRoundTrip(t *testing.T) {
+	p := &proofResponse{
+		publicInputs: [][32]byte{testField(9)},
+		proof:        [][32]byte{testField(1), testField(0xff)},
+		extra:        []msgpackEntry{{key: "vk", value: []byte{0x90}}},
+	}
+	decoded, err := decodeProof(p.encode())
+	if err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	if !bytes.Equal(decoded.encode(), p.encode()) {
+		t.Fatalf("proof did not round-trip")
+	}
+	if len(decoded.extra) != 1 || decoded.extra[0].key != "vk" {
+		t.Fatalf("unknown fields were not preserved: %+v", decoded.extra)
+	}
+
+	if _, err := decodeProof(p.encode()[:10]); err == nil {
+		t.Fatalf("expected error decoding a truncated proof")
+	}
+}
+
+func TestNormalizeWASMProof(t *testing.T) {
+	pub := testField(9)
+	f1, f2 := testField(1), testField(2)
+	raw := append(f1[:], f2[:]...)
+
+	normalized, err := NormalizeWASMProof(raw, pub)
+	if err != nil {
+		t.Fatalf("failed to normalize proof: %v", err)
+	}
+	p, err := decodeProof(normalized)
+	if err != nil {
+		t.Fatalf("normalized proof does not decode: %v", err)
+	}
+	if len(p.publicInputs) != 1 || p.publicInputs[0] != pub || len(p.proof) != 2 {
+		t.Fatalf("unexpected normalized proof: %+v", p)
+	}
+
+	// Already normalized proofs are left alone.
+	again, err := NormalizeWASMProof(normalized)
+	if err != nil || !bytes.Equal(again, normalized) {
+		t.Fatalf("expected native proof to be returned unchanged, err=%v", err)
+	}
+
+	if _, err := NormalizeWASMProof(raw[:40]); err == nil {
+		t.Fatalf("expected error for a proof that is not a multiple of 32 bytes")
+	}
+}
+
+// wasmFixture reads the proof of testdata/circuit produced by bb.js, see testdata/wasm/prove.mjs.
+func wasmFixture(t *testing.T) (proof, vk []byte, publicInputs [][32]byte, settings ProofSystemSettings) {
+	t.Helper()
+	proof, err := os.ReadFile("testdata/wasm/proof")
+	if err != nil {
+		t.Fatalf("failed to read the bb.js proof, run prove.mjs in testdata/wasm: %v", err)
+	}
+	if vk, err = os.ReadFile("testdata/wasm/vk"); err != nil {
+		t.Fatalf("failed to read the bb.js verification key: %v", err)
+	}
+	data, err := os.ReadFile("testdata/wasm/proof.json")
+	if err != nil {
+		t.Fatalf("failed to read the bb.js proof metadata: %v", err)
+	}
+	var meta struct {
+		PublicInputs []string       `json:"public_inputs"`
+		Oracle       OracleHashType `json:"oracle"`
+		DisableZk    bool           `json:"disable_zk"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("failed to unmarshal the bb.js proof metadata: %v", err)
+	}
+	for _, s := range meta.PublicInputs {
+		f, err := parseField(s)
+		if err != nil {
+			t.Fatalf("invalid bb.js public input %q: %v", s, err)
+		}
+		publicInputs = append(publicInputs, f)
+	}
+	settings = DefaultSettings()
+	settings.OracleHashType = meta.Oracle
+	settings.DisableZk = meta.DisableZk
+	return proof, vk, publicInputs, settings
+}
+
+func TestVerifyWASMProof(t *testing.T) {
+	proof, vk, publicInputs, settings := wasmFixture(t)
+
+	normalized, err := NormalizeWASMProof(proof, publicInputs...)
+	if err != nil {
+		t.Fatalf("failed to normalize proof: %v", err)
+	}
+	if err := VerifyUltraHonkErr(normalized, vk, settings); err != nil {
+		t.Fatalf("bb.js proof failed verification: %v", err)
+	}
+
+	// The public inputs must be passed as bb.js returned them.
+	missing, err := NormalizeWASMProof(proof, publicInputs[1:]...)
+	if err != nil {
+		t.Fatalf("failed to normalize proof: %v", err)
+	}
+	if err := VerifyUltraHonkErr(missing, vk, settings); err == nil {
+		t.Fatalf("expected a proof with a missing public input to be rejected")
+	}
+
+	// A ZK proof does not have the layout of a non-ZK one.
+	other := settings
+	other.DisableZk = !settings.DisableZk
+	if err := VerifyUltraHonkErr(normalized, vk, other); err == nil {
+		t.Fatalf("expected the proof to be rejected with DisableZk=%v", other.DisableZk)
+	}
+}
+
+func TestExtractPairingPoints(t *testing.T) {
+	p := testProof([][32]byte{testField(9)}, false)
+	points, err := ExtractPairingPoints(p.encode(), DefaultSettings())
+	if err != nil {
+		t.Fatalf("failed to extract pairing points: %v", err)
+	}
+	if len(points) != pairingPointsSize || points[3] != Fr(testField(3)) {
+		t.Fatalf("unexpected pairing points: %v", points)
+	}
+
+	settings := DefaultSettings()
+	settings.IpaAccumulation = true
+	if _, err := ExtractPairingPoints(p.encode(), settings); err == nil {
+		t.Fatalf("expected error when the proof is too short for the IPA claim")
+	}
+}
+
+func TestPublicInputs(t *testing.T) {
+	p := testProof([][32]byte{testField(9), testField(3)}, false)
+	inputs, err := PublicInputs(p.encode(), DefaultSettings())
+	if err != nil {
+		t.Fatalf("failed to extract public inputs: %v", err)
+	}
+	if len(inputs) != 2 || inputs[0] != Fr(testField(9)).String() || inputs[1] != Fr(testField(3)).String() {
+		t.Fatalf("unexpected public inputs: %v", inputs)
+	}
+
+	settings := DefaultSettings()
+	settings.IpaAccumulation = true
+	if _, err := PublicInputs(p.encode(), settings); err == nil {
+		t.Fatalf("expected error when the proof is too short for the IPA claim")
+	}
+	if _, err := PublicInputs(p.encode()[:20], DefaultSettings()); err == nil {
+		t.Fatalf("expected error for a truncated proof")
+	}
+}
+
+func TestProofAsFields(t *testing.T) {
+	p := &proofResponse{
+		publicInputs: [][32]byte{testField(9)},
+		proof:        [][32]byte{testField(1), testField(2)},
+	}
+	fields, err := ProofAsFields(p.encode())
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	want := "0x0000000000000000000000000000000000000000000000000000000000000002"
+	if len(fields) != 2 || fields[1] != want {
+		t.Fatalf("unexpected proof fields: %v", fields)
+	}
+}
+
+// testVK builds a verification key header for a circuit with the given number of public inputs.
+func testVK(logCircuitSize, numPublicInputs byte) []byte {
+	vk := make([]byte, vkHeaderSize+g1PointSize)
+	vk[fieldSize-1] = logCircuitSize
+	vk[2*fieldSize-1] = numPublicInputs
+	return vk
+}
+
+func TestCanonicalProof(t *testing.T) {
+	p := testProof([][32]byte{testField(9)}, false)
+	vk := testVK(5, 1+pairingPointsSize)
+
+	// Encode the same proof with bin byte strings and a 16-bit map header.
+	b := []byte{0xde, 0x00, 0x02}
+	b = appendString(b, "proof")
+	b = appendArrayHeader(b, len(p.proof))
+	for _, f := range p.proof {
+		b = append(append(b, 0xc4, fieldSize), f[:]...)
+	}
+	b = appendString(b, "public_inputs")
+	b = appendArrayHeader(b, 1)
+	b = append(append(b, 0xc4, fieldSize), p.publicInputs[0][:]...)
+
+	want, err := CanonicalProof(p.encode(), vk)
+	if err != nil {
+		t.Fatalf("failed to canonicalize proof: %v", err)
+	}
+	got, err := CanonicalProof(b, vk)
+	if err != nil {
+		t.Fatalf("failed to canonicalize alternative encoding: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("canonical encodings differ")
+	}
+
+	if _, err := CanonicalProof(p.encode(), testVK(5, 3+pairingPointsSize)); err == nil {
+		t.Fatalf("expected error for a public input count mismatch")
+	}
+}
+
+func TestInspectProof(t *testing.T) {
+	p := testProof([][32]byte{testField(9)}, true)
+	meta, err := InspectProof(p.encode())
+	if err != nil {
+		t.Fatalf("failed to inspect proof: %v", err)
+	}
+	if meta.ByteLen != len(p.encode()) || meta.NumPublicInputs != 1 || meta.HasIpaClaim || meta.Flavor != "UltraFlavor" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+
+	// An IPA claim: small limbs followed by the Grumpkin generator.
+	claim := p.proof[pairingPointsSize : pairingPointsSize+ipaClaimSize]
+	for i := range claim[:ipaClaimSize-2] {
+		claim[i] = [32]byte{31: byte(i)}
+	}
+	claim[ipaClaimSize-2] = [32]byte{31: 1}
+	y, _ := new(big.Int).SetString("17631683881184975370165255887551781615748388533673675138860", 10)
+	y.FillBytes(claim[ipaClaimSize-1][:])
+	meta, err = InspectProof(p.encode())
+	if err != nil {
+		t.Fatalf("failed to inspect proof: %v", err)
+	}
+	if !meta.HasIpaClaim || meta.Flavor != "UltraRollupFlavor" {
+		t.Fatalf("expected an IPA claim, got %+v", meta)
+	}
+
+	p.proof = p.proof[:pairingPointsSize-1]
+	if _, err := InspectProof(p.encode()); err == nil {
+		t.Fatalf("expected error for a proof without pairing points")
+	}
+	if _, err := InspectProof([]byte{0x92, 0x01}); err == nil {
+		t.Fatalf("expected error for a buffer that is not a proof")
+	}
+}
+
+func TestProofToEvmCalldata(t *testing.T) {
+	p := testProof([][32]byte{testField(9), testField(3)}, false)
+	settings := DefaultSettings()
+	settings.OracleHashType = HashKeccak
+
+	proofBytes, publicInputs, err := ProofToEvmCalldata(p.encode(), settings)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if len(proofBytes) != len(p.proof)*fieldSize || !bytes.Equal(proofBytes[3*fieldSize:4*fieldSize], p.proof[3][:]) {
+		t.Fatalf("unexpected proof bytes: %x", proofBytes)
+	}
+	inputs, _ := PublicInputs(p.encode(), settings)
+	if len(publicInputs) != len(inputs) {
+		t.Fatalf("expected %d public inputs, got %d", len(inputs), len(publicInputs))
+	}
+	for i := range inputs {
+		if Fr(publicInputs[i]).String() != inputs[i] {
+			t.Fatalf("public input %d is %x, PublicInputs returns %s", i, publicInputs[i], inputs[i])
+		}
+	}
+
+	if _, _, err := ProofToEvmCalldata(p.encode(), DefaultSettings()); err == nil {
+		t.Fatalf("expected error for the poseidon2 oracle hash")
+	}
+}
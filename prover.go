@@ -0,0 +1,112 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// ErrProverClosed is returned when using a ProverContext after Close.
+var ErrProverClosed = errors.New("prover context is closed")
+
+// ProverContext proves the same circuit for many witnesses.
+// The bytecode is decoded and the verification key computed once, when the context is created,
+// instead of on every ProveUltraHonk call. The proving key is still built by the backend on
+// every proof: the backend keeps no state between commands, so nothing it derives from the
+// circuit can be cached across calls.
+// A ProverContext is safe for concurrent use. The native side of the context is immutable, and
+// the shim runs one backend command at a time, so concurrent proofs are queued in the backend,
+// which parallelizes each proof internally.
+type ProverContext struct {
+	mu     sync.RWMutex
+	prover *C.BBProver
+	vk     []byte
+}
+
+// NewProverContext prepares the circuit for proving with the given settings.
+// The context must be released with Close.
+func NewProverContext(bytecode string, settings ProofSystemSettings) (*ProverContext, error) {
+	cBytecode := C.CString(bytecode)
+	defer C.free(unsafe.Pointer(cBytecode))
+
+	cSettings, err := settingsCString(settings)
+	if err != nil {
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(cSettings))
+
+	var prover *C.BBProver
+	backendMu.RLock()
+	r := C.bb_prover_new(cBytecode, cSettings, &prover)
+	backendMu.RUnlock()
+	vk, err := resultToBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	return &ProverContext{prover: prover, vk: vk}, nil
+}
+
+// Prove generates a proof for the given witness JSON, as ProveUltraHonk does.
+func (p *ProverContext) Prove(witnessJson string) ([]byte, error) {
+	cWitness := C.CString(witnessJson)
+	defer C.free(unsafe.Pointer(cWitness))
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.prover == nil {
+		return nil, ErrProverClosed
+	}
+	backendMu.RLock()
+	r := C.bb_prover_prove(p.prover, cWitness)
+	backendMu.RUnlock()
+	return resultToBytes(r)
+}
+
+// ProveBatch proves each witness JSON, running up to parallelism proofs at a time, or
+// GOMAXPROCS if parallelism is 0 or less. It returns the proof and error of each witness at
+// the same position, so a failing witness doesn't affect the others.
+// As the backend runs one command at a time, parallelism overlaps the Go side and the native
+// encoding of the inputs with proving, rather than running several proofs at once.
+func (p *ProverContext) ProveBatch(witnesses []string, parallelism int) ([][]byte, []error) {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	proofs := make([][]byte, len(witnesses))
+	errs := make([]error, len(witnesses))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallelism)
+	for i, w := range witnesses {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			proofs[i], errs[i] = p.Prove(w)
+		}()
+	}
+	wg.Wait()
+	return proofs, errs
+}
+
+// VK returns the verification key of the circuit.
+func (p *ProverContext) VK() []byte {
+	return append([]byte(nil), p.vk...)
+}
+
+// Close releases the native resources held by the context. It is safe to call Close more than once.
+func (p *ProverContext) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.prover != nil {
+		C.bb_prover_free(p.prover)
+		p.prover = nil
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package barretenberg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Receipt is a self-contained record of a verification, suitable for logging or signing.
+// Hashes are hex-encoded SHA-256 digests of the exact bytes that were verified.
+type Receipt struct {
+	Verified       bool                `json:"verified"`
+	ErrorCode      string              `json:"error_code,omitempty"` // class of a backend rejection, e.g. "invalid proof"
+	Error          string              `json:"error,omitempty"`      // why verification failed, empty if it succeeded
+	Timestamp      time.Time           `json:"timestamp"`
+	ProofHash      string              `json:"proof_hash"`
+	VKHash         string              `json:"vk_hash"`
+	Settings       ProofSystemSettings `json:"settings"`
+	Backend        BackendType         `json:"backend"`
+	BackendVersion string              `json:"backend_version"`
+}
+
+// VerifyUltraHonkReceipt verifies a proof like VerifyUltraHonk and returns a Receipt recording
+// the result. A proof that fails verification is not an error: the receipt records Verified as
+// false, together with the reason returned by VerifyUltraHonkErr and, when the backend rejected
+// the proof, the name of its ErrorCode. An error is returned only if the proof can't be verified at all.
+func VerifyUltraHonkReceipt(proof []byte, vk []byte, settings ProofSystemSettings) (*Receipt, error) {
+	if len(proof) == 0 || len(vk) == 0 {
+		return nil, errors.New("empty proof or verification key")
+	}
+	if err := CheckVerifierMemoryLimit(proof, vk); err != nil {
+		return nil, err
+	}
+	proofHash := sha256.Sum256(proof)
+	vkHash := sha256.Sum256(vk)
+	verifyErr := VerifyUltraHonkErr(proof, vk, settings)
+	// An incompatible library version is recorded as is, the receipt shows which one verified.
+	version, _ := Version()
+	r := &Receipt{
+		Verified:       verifyErr == nil,
+		Timestamp:      time.Now().UTC(),
+		ProofHash:      hex.EncodeToString(proofHash[:]),
+		VKHash:         hex.EncodeToString(vkHash[:]),
+		Settings:       settings,
+		Backend:        GetBackendType(),
+		BackendVersion: version,
+	}
+	if verifyErr != nil {
+		r.Error = verifyErr.Error()
+		var be *BackendError
+		if errors.As(verifyErr, &be) {
+			r.ErrorCode = be.Code.Error()
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,63 @@
+package barretenberg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RecursionInputs holds the arguments an outer circuit needs to verify an inner proof, as
+// 0x-prefixed hex field elements. The JSON names follow the parameters of Noir's recursive
+// verification examples, so the struct can be merged into the outer circuit's inputs.
+type RecursionInputs struct {
+	Proof           []string `json:"proof"`            // see ProofAsFields
+	VerificationKey []string `json:"verification_key"` // see VkAsFields
+	PublicInputs    []string `json:"public_inputs"`    // see PublicInputs
+	KeyHash         string   `json:"key_hash"`         // Poseidon2 hash of the verification key
+	NumPublicInputs int      `json:"-"`                // len(PublicInputs), the size of the outer circuit's array
+}
+
+// PrepareRecursionInputs converts an inner proof and its verification key into the inputs of
+// an outer circuit that verifies it recursively.
+// The inner proof must have been produced with settings, which must enable IpaAccumulation: the
+// proof must carry an IPA claim, as detected by InspectProof, and the proof and key are checked
+// with CheckVerifyCompatibility, so a mismatch is reported rather than failing inside the outer
+// circuit. The key hash is the one the recursive verifier constrains, always computed with
+// Poseidon2 whatever settings.VKHashType is.
+func PrepareRecursionInputs(innerProof, innerVk []byte, settings ProofSystemSettings) (RecursionInputs, error) {
+	var inputs RecursionInputs
+	if !settings.IpaAccumulation {
+		return inputs, errors.New("recursion inputs require a proof produced with settings.IpaAccumulation")
+	}
+	if err := settings.Validate(); err != nil {
+		return inputs, err
+	}
+	meta, err := InspectProof(innerProof)
+	if err != nil {
+		return inputs, err
+	}
+	if !meta.HasIpaClaim {
+		return inputs, errors.New("inner proof carries no IPA claim, prove it with settings.IpaAccumulation")
+	}
+	if err := CheckVerifyCompatibility(innerProof, innerVk, settings); err != nil {
+		return inputs, fmt.Errorf("inner proof does not match the recursion settings: %w", err)
+	}
+
+	if inputs.Proof, err = ProofAsFields(innerProof); err != nil {
+		return inputs, err
+	}
+	if inputs.PublicInputs, err = PublicInputs(innerProof, settings); err != nil {
+		return inputs, err
+	}
+	if inputs.VerificationKey, err = VkAsFields(innerVk); err != nil {
+		return inputs, err
+	}
+	hashSettings := settings
+	hashSettings.VKHashType = HashPoseidon2
+	keyHash, err := SolidityVKHash(innerVk, hashSettings)
+	if err != nil {
+		return inputs, err
+	}
+	inputs.KeyHash = keyHash.String()
+	inputs.NumPublicInputs = len(inputs.PublicInputs)
+	return inputs, nil
+}
@@ -0,0 +1,105 @@
+package barretenberg
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CircuitRegistry holds a set of circuits keyed by name. Each circuit is prepared once at
+// registration with a ProverContext, so its bytecode is decoded and its verification key computed
+// only once. A CircuitRegistry is safe for concurrent use.
+type CircuitRegistry struct {
+	mu       sync.RWMutex
+	circuits map[string]*registeredCircuit
+}
+
+type registeredCircuit struct {
+	prover   *ProverContext
+	verifier *Verifier
+}
+
+// NewCircuitRegistry returns an empty registry. It must be released with Close.
+func NewCircuitRegistry() *CircuitRegistry {
+	return &CircuitRegistry{circuits: make(map[string]*registeredCircuit)}
+}
+
+// Register adds a circuit under name, computing its verification key with the given settings.
+// It returns an error if name is already registered.
+func (r *CircuitRegistry) Register(name string, bytecode string, settings ProofSystemSettings) error {
+	r.mu.RLock()
+	_, exists := r.circuits[name]
+	r.mu.RUnlock()
+	if exists {
+		return fmt.Errorf("circuit %q is already registered", name)
+	}
+
+	p, err := NewProverContext(bytecode, settings)
+	if err != nil {
+		return fmt.Errorf("circuit %q: %w", name, err)
+	}
+	v, err := NewVerifier(p.VK(), settings)
+	if err != nil {
+		p.Close()
+		return fmt.Errorf("circuit %q: %w", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.circuits[name]; exists {
+		p.Close()
+		v.Close()
+		return fmt.Errorf("circuit %q is already registered", name)
+	}
+	r.circuits[name] = &registeredCircuit{prover: p, verifier: v}
+	return nil
+}
+
+func (r *CircuitRegistry) get(name string) (*registeredCircuit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.circuits[name]
+	if !ok {
+		return nil, fmt.Errorf("circuit %q is not registered", name)
+	}
+	return c, nil
+}
+
+// VK returns the verification key of the named circuit.
+func (r *CircuitRegistry) VK(name string) ([]byte, error) {
+	c, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.prover.VK(), nil
+}
+
+// Prove generates a proof for the named circuit with its registered settings, through the
+// circuit's ProverContext.
+func (r *CircuitRegistry) Prove(name string, witnessJson string) ([]byte, error) {
+	c, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.prover.Prove(witnessJson)
+}
+
+// Verify reports whether proof is valid for the named circuit, and why not otherwise, see Verifier.Verify.
+func (r *CircuitRegistry) Verify(name string, proof []byte) (bool, error) {
+	c, err := r.get(name)
+	if err != nil {
+		return false, err
+	}
+	return c.verifier.Verify(proof)
+}
+
+// Close releases the native memory held by the registered circuits, which can't be used afterwards.
+func (r *CircuitRegistry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, c := range r.circuits {
+		c.prover.Close()
+		c.verifier.Close()
+		delete(r.circuits, name)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package barretenberg
+
+// peakRSS is not available on platforms without getrusage.
+func peakRSS() (self, children int64, ok bool) {
+	return 0, 0, false
+}
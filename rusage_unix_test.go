@@ -0,0 +1,23 @@
+//go:build unix
+
+package barretenberg
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// peakRSS returns the peak resident set size, in bytes, of this process and of its waited-for
+// children, such as the `bb` subprocess of BackendPipe.
+func peakRSS() (self, children int64, ok bool) {
+	var s, c syscall.Rusage
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &s) != nil || syscall.Getrusage(syscall.RUSAGE_CHILDREN, &c) != nil {
+		return 0, 0, false
+	}
+	// Maxrss is in bytes on macOS and in kilobytes elsewhere.
+	unit := int64(1024)
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		unit = 1
+	}
+	return int64(s.Maxrss) * unit, int64(c.Maxrss) * unit, true
+}
@@ -0,0 +1,75 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// WriteSolidityVerifier returns the Solidity source of a verifier contract for vk.
+// The settings must be those used to compute the key and prove. On-chain verification
+// requires the Keccak oracle hash; settings.OptimizedSolidityVerifier selects the gas
+// optimized contract. The contract embeds the Keccak VK hash, so settings.VKHashType must be
+// unset or HashKeccak.
+func WriteSolidityVerifier(vk []byte, settings ProofSystemSettings) (string, error) {
+	if len(vk) == 0 {
+		return "", errors.New("empty verification key")
+	}
+	if settings.OracleHashType != HashKeccak {
+		return "", fmt.Errorf("solidity verifier requires the %q oracle hash, got %q", HashKeccak, settings.OracleHashType)
+	}
+	if settings.vkHashType() != HashKeccak {
+		return "", fmt.Errorf("solidity verifier embeds the %q VK hash, got VKHashType %q", HashKeccak, settings.VKHashType)
+	}
+
+	cSettings, err := settingsCString(settings)
+	if err != nil {
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cSettings))
+
+	backendMu.RLock()
+	r := C.bb_write_solidity_verifier(
+		(*C.uint8_t)(unsafe.Pointer(&vk[0])),
+		C.uintptr_t(len(vk)),
+		cSettings,
+	)
+	backendMu.RUnlock()
+	code, err := resultToBytes(r)
+	if err != nil {
+		return "", err
+	}
+	return string(code), nil
+}
+
+// ProofToEvmCalldata splits a proof into the two arguments of the Solidity verifier's
+// verify(bytes proof, bytes32[] publicInputs): the proof elements, starting with the pairing
+// point accumulator, as concatenated 32-byte words, and the circuit's public inputs in the
+// order returned by PublicInputs. Only proofs using the Keccak oracle hash can be verified on-chain.
+func ProofToEvmCalldata(proof []byte, settings ProofSystemSettings) (proofBytes []byte, publicInputs [][32]byte, err error) {
+	if settings.OracleHashType != HashKeccak {
+		return nil, nil, fmt.Errorf("EVM verification requires the %q oracle hash, got %q", HashKeccak, settings.OracleHashType)
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, nil, err
+	}
+	p, err := decodeProof(proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := p.pairingPoints(settings); err != nil {
+		return nil, nil, err
+	}
+	proofBytes = make([]byte, 0, len(p.proof)*fieldSize)
+	for i := range p.proof {
+		proofBytes = append(proofBytes, p.proof[i][:]...)
+	}
+	publicInputs = make([][32]byte, len(p.publicInputs))
+	copy(publicInputs, p.publicInputs)
+	return proofBytes, publicInputs, nil
+}
@@ -0,0 +1,78 @@
+package barretenberg
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// srsManifest describes a published SRS file, as listed in a trusted setup transcript.
+type srsManifest struct {
+	NumPoints uint64 `json:"num_points"` // number of G1 points in the file
+	SHA256    string `json:"sha256"`     // hex SHA-256 of the file contents
+}
+
+// VerifySRSTrustedSetup checks that the G1 SRS file at srsPath is the canonical output of a
+// trusted setup, described by transcriptManifest as JSON `{"num_points": N, "sha256": "<hex>"}`.
+// The file is the flat list of uncompressed G1 points used by Barretenberg (bn254_g1.dat):
+// 64 bytes per point, x then y, big-endian. Besides the checksum and point count from the
+// manifest, every point is checked to be on the curve and the first one, tau^0 * G, to be the
+// generator. The file is streamed, so large SRS files can be checked with constant memory.
+// This does not check the pairing relations between consecutive powers.
+func VerifySRSTrustedSetup(srsPath string, transcriptManifest []byte) error {
+	var manifest srsManifest
+	if err := json.Unmarshal(transcriptManifest, &manifest); err != nil {
+		return fmt.Errorf("invalid SRS manifest: %w", err)
+	}
+	want, err := hex.DecodeString(strings.TrimPrefix(manifest.SHA256, "0x"))
+	if err != nil || len(want) != sha256.Size {
+		return errors.New("invalid SRS manifest: sha256 must be a hex SHA-256 digest")
+	}
+	if manifest.NumPoints == 0 {
+		return errors.New("invalid SRS manifest: num_points must be positive")
+	}
+
+	f, err := os.Open(srsPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	r := bufio.NewReaderSize(io.TeeReader(f, h), 1<<20)
+	var point [g1PointSize]byte
+	x, y := new(big.Int), new(big.Int)
+	var n uint64
+	for ; ; n++ {
+		if _, err := io.ReadFull(r, point[:]); err == io.EOF {
+			break
+		} else if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("SRS file is truncated after %d points", n)
+		} else if err != nil {
+			return err
+		}
+		x.SetBytes(point[:32])
+		y.SetBytes(point[32:])
+		if n == 0 && !isG1Generator(point[:]) {
+			return errors.New("first SRS point is not the G1 generator")
+		}
+		if !isOnCurveG1(x, y) {
+			return fmt.Errorf("SRS point %d is not on the curve", n)
+		}
+	}
+	if n != manifest.NumPoints {
+		return fmt.Errorf("SRS file has %d points, manifest expects %d", n, manifest.NumPoints)
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		return fmt.Errorf("SRS checksum mismatch: got %x, manifest expects %x", got, want)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package barretenberg
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// srsDownloadURL serves the Ignition G1 points in the format of bn254_g1.dat, and supports
+// range requests. It is the source Barretenberg itself downloads from.
+var srsDownloadURL = "https://crs.aztec.network/g1.dat"
+
+// srsCacheFile is the name of the G1 SRS file kept in the cache directory.
+const srsCacheFile = "bn254_g1.dat"
+
+// SRSDownloadError is returned by InitSRSWithCache when the SRS could not be downloaded, e.g.
+// because the network is unavailable. The download can be retried; points already cached are kept.
+type SRSDownloadError struct {
+	URL string
+	Err error
+}
+
+func (e *SRSDownloadError) Error() string {
+	return fmt.Sprintf("failed to download SRS from %s: %v", e.URL, e.Err)
+}
+
+func (e *SRSDownloadError) Unwrap() error {
+	return e.Err
+}
+
+// InitSRSWithCache loads an SRS large enough for the circuit, downloading it on demand.
+// The SRS is kept in cacheDir as bn254_g1.dat, and only the points it lacks are downloaded, so
+// later calls work offline for circuits of the same or a smaller size. An empty cacheDir means
+// barretenberg under the user cache directory, $XDG_CACHE_HOME/barretenberg on Linux.
+// The required size is the circuit's subgroup size with settings, which must be the ones the
+// circuit is proved with, see CircuitInfo.
+// Downloaded points are checked to be on the curve, and the first one to be the G1 generator; a
+// cached file that doesn't start with the generator is downloaded again.
+// Several processes can share cacheDir: the cache file is only ever replaced by an atomic rename.
+// Download failures are returned as *SRSDownloadError.
+func InitSRSWithCache(bytecode string, settings ProofSystemSettings, cacheDir string) error {
+	stats, err := CircuitInfo(bytecode, settings)
+	if err != nil {
+		return err
+	}
+	if cacheDir == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return fmt.Errorf("no SRS cache directory: %w", err)
+		}
+		cacheDir = filepath.Join(dir, "barretenberg")
+	}
+	path, err := ensureSRSCache(cacheDir, stats.SubgroupSize)
+	if err != nil {
+		return err
+	}
+	return InitSRSFromFile(path)
+}
+
+// ensureSRSCache makes sure the SRS file in dir holds at least numPoints points, and returns its path.
+func ensureSRSCache(dir string, numPoints uint64) (string, error) {
+	if numPoints == 0 {
+		return "", errors.New("no SRS points requested")
+	}
+	path := filepath.Join(dir, srsCacheFile)
+	have := cachedSRSPoints(path)
+	if have >= numPoints {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	// Build the larger file next to the cache and rename it into place, so readers never see
+	// a partial file and concurrent downloads each install a complete one.
+	tmp, err := os.CreateTemp(dir, srsCacheFile+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if have > 0 {
+		if have, err = copySRSPrefix(tmp, path, have); err != nil {
+			return "", err
+		}
+	}
+	if err := downloadSRSPoints(tmp, have, numPoints); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	// Another process may have installed a larger SRS in the meantime; keep it.
+	if cachedSRSPoints(path) >= numPoints {
+		return path, nil
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// cachedSRSPoints returns the number of complete points in the SRS file at path, 0 if there is
+// none or if it doesn't start with the G1 generator, so that a substituted file is downloaded again.
+func cachedSRSPoints(path string) uint64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	var first [g1PointSize]byte
+	if _, err := io.ReadFull(f, first[:]); err != nil || !isG1Generator(first[:]) {
+		return 0
+	}
+	return uint64(fi.Size()) / g1PointSize
+}
+
+// copySRSPrefix copies the first n points of the SRS file at path to w, returning the number
+// of points copied, which is less than n if the file was replaced by a smaller one.
+func copySRSPrefix(w io.Writer, path string, n uint64) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer f.Close()
+	copied, err := io.CopyN(w, f, int64(n*g1PointSize))
+	if err == io.EOF {
+		err = nil
+	}
+	return uint64(copied) / g1PointSize, err
+}
+
+// downloadSRSPoints appends points from..to-1 of the published SRS to w, checking each is on the curve.
+func downloadSRSPoints(w io.Writer, from, to uint64) error {
+	req, err := http.NewRequest(http.MethodGet, srsDownloadURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from*g1PointSize, to*g1PointSize-1))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return &SRSDownloadError{URL: srsDownloadURL, Err: err}
+	}
+	defer resp.Body.Close()
+
+	body := io.Reader(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The server ignored the range and sends the whole file: skip the points we have.
+		if _, err := io.CopyN(io.Discard, body, int64(from*g1PointSize)); err != nil {
+			return &SRSDownloadError{URL: srsDownloadURL, Err: err}
+		}
+	default:
+		return &SRSDownloadError{URL: srsDownloadURL, Err: fmt.Errorf("unexpected status %s", resp.Status)}
+	}
+
+	r := bufio.NewReaderSize(body, 1<<20)
+	var point [g1PointSize]byte
+	x, y := new(big.Int), new(big.Int)
+	for n := from; n < to; n++ {
+		if _, err := io.ReadFull(r, point[:]); err != nil {
+			return &SRSDownloadError{URL: srsDownloadURL, Err: fmt.Errorf("download ended after %d points: %w", n, err)}
+		}
+		x.SetBytes(point[:32])
+		y.SetBytes(point[32:])
+		if n == 0 && !isG1Generator(point[:]) {
+			return errors.New("downloaded SRS does not start with the G1 generator")
+		}
+		if !isOnCurveG1(x, y) {
+			return fmt.Errorf("downloaded SRS point %d is not on the curve", n)
+		}
+		if _, err := w.Write(point[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
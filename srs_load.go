@@ -0,0 +1,80 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// srsG2Point is tau * G2 from the Aztec Ignition ceremony, the G2 half of the SRS used by
+// Barretenberg (bn254_g2.dat). It is serialized as x.c0, x.c1, y.c0, y.c1, 32 bytes big-endian
+// each, where x = x.c0 + x.c1 * u in the quadratic extension of the base field.
+var srsG2Point = func() []byte {
+	b, err := hex.DecodeString("" +
+		"0118c4d5b837bcc2bc89b5b398b5974e9f5944073b32078b7e231fec938883b0" +
+		"260e01b251f6f1c7e7ff4e580791dee8ea51d87a358e038b4efe30fac09383c1" +
+		"22febda3c0c0632a56475b4214e5615e11e6dd3f96e6cea2854a87d4dacc5e55" +
+		"04fc6369f7110fe3d25156c1bb9a72859cf2a04641f99ba4ee413c80da6a5fe4")
+	if err != nil {
+		panic(err)
+	}
+	return b
+}()
+
+// InitSRSFromFile loads the G1 SRS from a local file, so the backend doesn't download it.
+// The file is the flat list of uncompressed G1 points of the Ignition ceremony used by
+// Barretenberg (bn254_g1.dat), see VerifySRSTrustedSetup. Where supported, the file is
+// memory-mapped rather than read into the Go heap; the backend keeps its own copy.
+// Proving or computing the VK of a circuit larger than the SRS fails with ErrSRSTooSmall.
+func InitSRSFromFile(path string) error {
+	data, release, err := mapFile(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return InitSRSFromBytes(data)
+}
+
+// InitSRSFromBytes loads the G1 SRS from memory, in the format read by InitSRSFromFile.
+// The data is copied by the backend, so it can be reused once this returns.
+func InitSRSFromBytes(srs []byte) error {
+	if len(srs) == 0 {
+		return errors.New("empty SRS")
+	}
+	if len(srs)%g1PointSize != 0 {
+		return fmt.Errorf("invalid SRS: length %d is not a multiple of %d", len(srs), g1PointSize)
+	}
+	numPoints := len(srs) / g1PointSize
+	if numPoints > math.MaxUint32 {
+		return fmt.Errorf("invalid SRS: %d points is too many", numPoints)
+	}
+	// Only the generator is checked here: checking every point would read the whole file,
+	// use VerifySRSTrustedSetup for that.
+	if !isG1Generator(srs[:g1PointSize]) {
+		return errors.New("invalid SRS: first point is not the G1 generator")
+	}
+
+	backendMu.RLock()
+	r := C.bb_init_srs(
+		(*C.uint8_t)(unsafe.Pointer(&srs[0])),
+		C.uint32_t(numPoints),
+		(*C.uint8_t)(unsafe.Pointer(&srsG2Point[0])),
+	)
+	backendMu.RUnlock()
+	_, err := resultToBytes(r)
+	return err
+}
+
+// SRSInitialized reports whether an SRS was loaded with InitSRSFromFile or InitSRSFromBytes
+// since the backend started. Otherwise the backend downloads the SRS it needs on first use.
+// ResetBackend discards a loaded SRS.
+func SRSInitialized() bool {
+	return C.bb_srs_num_points() > 0
+}
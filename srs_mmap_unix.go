@@ -0,0 +1,34 @@
+//go:build unix
+
+package barretenberg
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// mapFile maps the file at path into memory read-only. release unmaps it.
+func mapFile(path string) (data []byte, release func(), err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil, errors.New("empty SRS file")
+	}
+	if int64(int(size)) != size {
+		return nil, nil, errors.New("SRS file too large to map")
+	}
+	data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() { syscall.Munmap(data) }, nil
+}
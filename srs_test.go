@@ -0,0 +1,153 @@
+package barretenberg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testSRS holds two G1 points in SRS file format: the generator and its double.
+const testSRS = "0000000000000000000000000000000000000000000000000000000000000001" +
+	"0000000000000000000000000000000000000000000000000000000000000002" +
+	"030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd3" +
+	"15ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4"
+
+func writeTestSRS(t *testing.T, data []byte) (path string, manifest []byte) {
+	t.Helper()
+	path = filepath.Join(t.TempDir(), "bn254_g1.dat")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	return path, []byte(fmt.Sprintf(`{"num_points": %d, "sha256": "%x"}`, len(data)/g1PointSize, sum))
+}
+
+func TestVerifySRSTrustedSetup(t *testing.T) {
+	data, _ := hex.DecodeString(testSRS)
+	path, manifest := writeTestSRS(t, data)
+	if err := VerifySRSTrustedSetup(path, manifest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A point off the curve is rejected even when the checksum matches.
+	bad := append([]byte{}, data...)
+	bad[len(bad)-1] ^= 1
+	path, manifest = writeTestSRS(t, bad)
+	if err := VerifySRSTrustedSetup(path, manifest); err == nil {
+		t.Fatalf("expected error for a point off the curve")
+	}
+
+	// A valid file that doesn't match the manifest is rejected.
+	path, _ = writeTestSRS(t, data)
+	_, other := writeTestSRS(t, data[:g1PointSize])
+	if err := VerifySRSTrustedSetup(path, other); err == nil {
+		t.Fatalf("expected error for a manifest mismatch")
+	}
+}
+
+func TestSRSG2PointOnTwist(t *testing.T) {
+	// Elements of Fq2 = Fq[u]/(u^2 + 1), as (c0, c1).
+	mod := func(v *big.Int) *big.Int { return v.Mod(v, fqModulus) }
+	mul := func(a, b [2]*big.Int) [2]*big.Int {
+		c0 := new(big.Int).Sub(new(big.Int).Mul(a[0], b[0]), new(big.Int).Mul(a[1], b[1]))
+		c1 := new(big.Int).Add(new(big.Int).Mul(a[0], b[1]), new(big.Int).Mul(a[1], b[0]))
+		return [2]*big.Int{mod(c0), mod(c1)}
+	}
+	coord := func(i int) *big.Int { return new(big.Int).SetBytes(srsG2Point[i*fieldSize : (i+1)*fieldSize]) }
+	x := [2]*big.Int{coord(0), coord(1)}
+	y := [2]*big.Int{coord(2), coord(3)}
+
+	// The twist is y^2 = x^3 + 3/(9+u), i.e. (9+u) * (y^2 - x^3) = 3.
+	lhs := mul(y, y)
+	x3 := mul(mul(x, x), x)
+	diff := [2]*big.Int{mod(new(big.Int).Sub(lhs[0], x3[0])), mod(new(big.Int).Sub(lhs[1], x3[1]))}
+	got := mul(diff, [2]*big.Int{big.NewInt(9), big.NewInt(1)})
+	if got[0].Cmp(big.NewInt(3)) != 0 || got[1].Sign() != 0 {
+		t.Fatalf("SRS G2 point is not on the twist")
+	}
+}
+
+func TestInitSRSFromBytesValidation(t *testing.T) {
+	data, _ := hex.DecodeString(testSRS)
+	if err := InitSRSFromBytes(data[:100]); err == nil {
+		t.Fatalf("expected error for a truncated SRS")
+	}
+	if err := InitSRSFromBytes(data[g1PointSize:]); err == nil {
+		t.Fatalf("expected error for an SRS not starting with the generator")
+	}
+	if err := InitSRSFromFile(filepath.Join(t.TempDir(), "missing.dat")); err == nil {
+		t.Fatalf("expected error for a missing file")
+	}
+}
+
+func TestEnsureSRSCache(t *testing.T) {
+	data, _ := hex.DecodeString(testSRS)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "g1.dat", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+	defer func(url string) { srsDownloadURL = url }(srsDownloadURL)
+	srsDownloadURL = srv.URL
+
+	dir := t.TempDir()
+	path, err := ensureSRSCache(dir, 1)
+	if err != nil {
+		t.Fatalf("failed to download SRS: %v", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data[:g1PointSize]) {
+		t.Fatalf("unexpected cached SRS after the first download")
+	}
+
+	// Growing the cache only fetches the missing point, and a cached size is served offline.
+	if _, err := ensureSRSCache(dir, 2); err != nil {
+		t.Fatalf("failed to grow SRS: %v", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Fatalf("unexpected cached SRS after growing it")
+	}
+	if _, err := ensureSRSCache(dir, 2); err != nil {
+		t.Fatalf("unexpected error for a cached SRS: %v", err)
+	}
+	want := []string{"bytes=0-63", "bytes=64-127"}
+	if fmt.Sprint(ranges) != fmt.Sprint(want) {
+		t.Fatalf("unexpected range requests %q, want %q", ranges, want)
+	}
+
+	// The server has no third point.
+	if _, err := ensureSRSCache(dir, 3); err == nil {
+		t.Fatalf("expected error downloading past the end of the SRS")
+	}
+	// A cached file that doesn't start with the generator is replaced.
+	if err := os.WriteFile(path, append(data[g1PointSize:], data[:g1PointSize]...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ensureSRSCache(dir, 2); err != nil {
+		t.Fatalf("failed to replace an invalid cached SRS: %v", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Fatalf("invalid cached SRS was not replaced")
+	}
+
+	// Neither is a download that doesn't.
+	data = append(data[g1PointSize:], data[:g1PointSize]...)
+	if _, err := ensureSRSCache(t.TempDir(), 2); err == nil {
+		t.Fatalf("expected error for a downloaded SRS not starting with the generator")
+	}
+
+	srv.Close()
+	var dlErr *SRSDownloadError
+	if _, err := ensureSRSCache(t.TempDir(), 1); !errors.As(err, &dlErr) {
+		t.Fatalf("expected SRSDownloadError for an unreachable server, got %v", err)
+	}
+}
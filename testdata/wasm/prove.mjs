@@ -0,0 +1,19 @@
+// Proves testdata/circuit with bb.js, writing the proof and verification key in the layout
+// bb.js returns them, for TestVerifyWASMProof. Run `nargo compile` in testdata/circuit first.
+import { readFileSync, writeFileSync } from 'node:fs';
+import { UltraHonkBackend } from '@aztec/bb.js';
+import { Noir } from '@noir-lang/noir_js';
+
+const circuit = JSON.parse(readFileSync('../circuit/target/circuit.json', 'utf8'));
+const noir = new Noir(circuit);
+const { witness } = await noir.execute({ x: 3, y: 9 });
+
+const backend = new UltraHonkBackend(circuit.bytecode);
+const { proof, publicInputs } = await backend.generateProof(witness);
+const vk = await backend.getVerificationKey();
+await backend.destroy();
+
+writeFileSync('proof', proof);
+writeFileSync('vk', vk);
+// bb.js proves with the Poseidon2 oracle and zero-knowledge by default.
+writeFileSync('proof.json', JSON.stringify({ public_inputs: publicInputs, oracle: 'poseidon2', disable_zk: false }, null, 2) + '\n');
@@ -0,0 +1,115 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// ErrVerifierClosed is returned when using a Verifier after Close.
+var ErrVerifierClosed = errors.New("verifier is closed")
+
+// Verifier verifies many proofs against the same verification key.
+// It is a copy-once convenience: the key and settings are validated and copied to native memory
+// once, so each Verify call only passes the proof across the FFI boundary. It does not hold a
+// parsed key: the native CircuitVerify command deserializes the key on every call.
+// A Verifier is safe for concurrent use.
+type Verifier struct {
+	mu        sync.RWMutex
+	vk        *C.uint8_t
+	vkLen     int
+	settings  ProofSystemSettings
+	cSettings *C.char
+}
+
+// NewVerifier creates a Verifier for the given verification key and settings.
+// The Verifier must be released with Close.
+func NewVerifier(vk []byte, settings ProofSystemSettings) (*Verifier, error) {
+	if len(vk) == 0 {
+		return nil, errors.New("empty verification key")
+	}
+	cSettings, err := settingsCString(settings)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{
+		vk:        (*C.uint8_t)(C.CBytes(vk)),
+		vkLen:     len(vk),
+		settings:  settings,
+		cSettings: cSettings,
+	}, nil
+}
+
+// Verify reports whether proof is valid for the Verifier's key. Otherwise it returns false and
+// the reason, as VerifyUltraHonkErr does: a *BackendError with code ErrInvalidProof or
+// ErrVkMismatch for a proof the backend rejects, or an error if the proof can't be verified at
+// all, e.g. because it is empty, it exceeds the limit set by SetVerifierMemoryLimit, or the
+// Verifier has been closed.
+func (v *Verifier) Verify(proof []byte) (bool, error) {
+	if len(proof) == 0 {
+		return false, errors.New("empty proof")
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.vk == nil {
+		return false, ErrVerifierClosed
+	}
+	vk := unsafe.Slice((*byte)(unsafe.Pointer(v.vk)), v.vkLen)
+	if err := CheckVerifierMemoryLimit(proof, vk); err != nil {
+		return false, err
+	}
+	if err := verifyPrepared(proof, vk, v.cSettings, v.settings); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Close releases the native memory held by the Verifier. It is safe to call Close more than once.
+func (v *Verifier) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.vk == nil {
+		return nil
+	}
+	C.free(unsafe.Pointer(v.vk))
+	C.free(unsafe.Pointer(v.cSettings))
+	v.vk = nil
+	v.cSettings = nil
+	return nil
+}
+
+// BenchmarkVerify verifies proof against vk repeatedly for the given duration and returns the
+// number of verifications per second, to help size verifier instances. It uses a single Verifier,
+// so the key and settings are only prepared once. Verification runs on the calling goroutine;
+// run several calls concurrently to measure throughput across cores.
+// It returns ErrVerificationFailed if the proof does not verify.
+func BenchmarkVerify(proof []byte, vk []byte, settings ProofSystemSettings, duration time.Duration) (verificationsPerSecond float64, err error) {
+	if duration <= 0 {
+		return 0, errors.New("benchmark duration must be positive")
+	}
+	v, err := NewVerifier(vk, settings)
+	if err != nil {
+		return 0, err
+	}
+	defer v.Close()
+
+	start := time.Now()
+	var n int
+	for n == 0 || time.Since(start) < duration {
+		if _, err := v.Verify(proof); err != nil {
+			if errors.Is(err, ErrInvalidProof) || errors.Is(err, ErrVkMismatch) {
+				return 0, fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+			}
+			return 0, err
+		}
+		n++
+	}
+	return float64(n) / time.Since(start).Seconds(), nil
+}
@@ -0,0 +1,39 @@
+package barretenberg
+
+/*
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"strings"
+)
+
+// NativeVersion is the version of libbarretenberg_ffi these bindings are written against.
+// Libraries of the same major and minor version are compatible.
+const NativeVersion = "0.1.0"
+
+// Version returns the version of the linked libbarretenberg_ffi. If it is not compatible with
+// NativeVersion, it returns the version together with an error: the bindings and the library
+// exchange proofs and settings in formats that change between releases, so a mismatch shows
+// up as proofs failing for no apparent reason.
+func Version() (string, error) {
+	data, err := resultToBytes(C.bb_version())
+	if err != nil {
+		return "", err
+	}
+	version := string(data)
+	if majorMinor(version) != majorMinor(NativeVersion) {
+		return version, fmt.Errorf("native library version %s is not compatible with the bindings, which expect %s", version, NativeVersion)
+	}
+	return version, nil
+}
+
+// majorMinor returns the major.minor prefix of a semantic version.
+func majorMinor(version string) string {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
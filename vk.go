@@ -0,0 +1,115 @@
+package barretenberg
+
+/*
+#include <stdlib.h>
+#include "libnoir_ffi/barretenberg_ffi.h"
+*/
+import "C"
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// An UltraHonk verification key is serialized as three 32-byte big-endian header words
+// followed by the commitments to the circuit's precomputed polynomials, as G1 points.
+const (
+	vkHeaderSize      = 3 * fieldSize
+	maxLogCircuitSize = 28 // largest circuit supported by the backend, 2^28 gates
+)
+
+// vkHeader holds the header words of a verification key.
+type vkHeader struct {
+	logCircuitSize  uint64
+	numPublicInputs uint64 // includes the pairing points and IPA claim added by the backend
+	pubInputsOffset uint64
+}
+
+// parseVKHeader reads the header of a serialized verification key.
+func parseVKHeader(vk []byte) (*vkHeader, error) {
+	if len(vk) < vkHeaderSize {
+		return nil, fmt.Errorf("verification key too short: %d bytes", len(vk))
+	}
+	var words [3]uint64
+	for i := range words {
+		w := vk[i*fieldSize : (i+1)*fieldSize]
+		for _, b := range w[:fieldSize-8] {
+			if b != 0 {
+				return nil, fmt.Errorf("invalid verification key: header word %d out of range", i)
+			}
+		}
+		words[i] = binary.BigEndian.Uint64(w[fieldSize-8:])
+	}
+	h := &vkHeader{logCircuitSize: words[0], numPublicInputs: words[1], pubInputsOffset: words[2]}
+	if h.logCircuitSize == 0 || h.logCircuitSize > maxLogCircuitSize {
+		return nil, fmt.Errorf("invalid verification key: log circuit size %d", h.logCircuitSize)
+	}
+	if h.numPublicInputs < pairingPointsSize {
+		return nil, fmt.Errorf("invalid verification key: %d public inputs", h.numPublicInputs)
+	}
+	return h, nil
+}
+
+// SolidityVKHash returns the hash of a verification key. The hash function is
+// settings.VKHashType, which defaults to the oracle hash but can be set independently, e.g. to
+// HashKeccak to check a Poseidon2 key from a contract of your own.
+// With HashKeccak it is the hash embedded in and checked by the Solidity verifier that
+// WriteSolidityVerifier generates. The hash embedded by bb is not decoupled from the oracle:
+// it always follows OracleHashType, which must be HashKeccak for the Solidity verifier.
+func SolidityVKHash(vk []byte, settings ProofSystemSettings) (Fr, error) {
+	var h Fr
+	if len(vk) == 0 {
+		return h, errors.New("empty verification key")
+	}
+	hashType := settings.vkHashType()
+	switch hashType {
+	case HashKeccak, HashPoseidon2:
+	default:
+		return h, fmt.Errorf("unsupported VK hash type %q", hashType)
+	}
+
+	cHashType := C.CString(string(hashType))
+	defer C.free(unsafe.Pointer(cHashType))
+
+	backendMu.RLock()
+	r := C.bb_vk_hash(
+		(*C.uint8_t)(unsafe.Pointer(&vk[0])),
+		C.uintptr_t(len(vk)),
+		cHashType,
+	)
+	backendMu.RUnlock()
+	data, err := resultToBytes(r)
+	if err != nil {
+		return h, err
+	}
+	if len(data) != len(h) {
+		return h, fmt.Errorf("unexpected VK hash size %d from backend", len(data))
+	}
+	copy(h[:], data)
+	return h, nil
+}
+
+// VkAsFields returns the verification key as 0x-prefixed hex field elements, in the layout a
+// recursive verifier circuit takes as its verification key argument.
+func VkAsFields(vk []byte) ([]string, error) {
+	if len(vk) == 0 {
+		return nil, errors.New("empty verification key")
+	}
+	backendMu.RLock()
+	r := C.bb_vk_as_fields((*C.uint8_t)(unsafe.Pointer(&vk[0])), C.uintptr_t(len(vk)))
+	backendMu.RUnlock()
+	data, err := resultToBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	elements, err := splitFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected VK fields from backend: %w", err)
+	}
+	fields := make([]string, len(elements))
+	for i := range elements {
+		fields[i] = Fr(elements[i]).String()
+	}
+	return fields, nil
+}
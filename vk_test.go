@@ -0,0 +1,19 @@
+package barretenberg
+
+import "testing"
+
+func TestVKHashType(t *testing.T) {
+	settings := DefaultSettings()
+	if settings.vkHashType() != HashPoseidon2 {
+		t.Fatalf("expected VK hash to default to the oracle hash, got %q", settings.vkHashType())
+	}
+	settings.VKHashType = HashKeccak
+	if settings.vkHashType() != HashKeccak {
+		t.Fatalf("expected VK hash type override, got %q", settings.vkHashType())
+	}
+
+	settings.VKHashType = HashBlake2s
+	if _, err := SolidityVKHash([]byte{1}, settings); err == nil {
+		t.Fatalf("expected error for an unsupported VK hash type")
+	}
+}
@@ -0,0 +1,50 @@
+package barretenberg
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// witnessFile is the witness format accepted by ProveUltraHonk.
+type witnessFile struct {
+	Witness []string `json:"witness"`
+}
+
+// parseWitness decodes a witness JSON string into field elements, indexed by witness index.
+func parseWitness(witnessJson string) ([][32]byte, error) {
+	var w witnessFile
+	if err := json.Unmarshal([]byte(witnessJson), &w); err != nil {
+		return nil, fmt.Errorf("invalid witness JSON: %w", err)
+	}
+	values := make([][32]byte, len(w.Witness))
+	for i, s := range w.Witness {
+		v, err := parseField(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid witness value %d: %w", i, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// parseField parses a field element given as a 0x-prefixed hex string or a decimal string,
+// returning its 32-byte big-endian encoding.
+func parseField(s string) ([32]byte, error) {
+	var out [32]byte
+	v, ok := new(big.Int), false
+	if hex, isHex := strings.CutPrefix(s, "0x"); isHex {
+		v, ok = v.SetString(hex, 16)
+	} else {
+		v, ok = v.SetString(s, 10)
+	}
+	if !ok || v.Sign() < 0 {
+		return out, fmt.Errorf("%q is not a valid field element", s)
+	}
+	if v.Cmp(frModulus) >= 0 {
+		return out, fmt.Errorf("%q is not less than the field modulus", s)
+	}
+	v.FillBytes(out[:])
+	return out, nil
+}
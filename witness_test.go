@@ -0,0 +1,26 @@
+package barretenberg
+
+import "testing"
+
+func TestParseField(t *testing.T) {
+	for _, s := range []string{"9", "0x09", "0x0000000000000000000000000000000000000000000000000000000000000009"} {
+		v, err := parseField(s)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", s, err)
+		}
+		if v != testField(9) {
+			t.Fatalf("unexpected value for %q: %x", s, v)
+		}
+	}
+	for _, s := range []string{"", "0x", "-1", "0xzz", frModulus.String()} {
+		if _, err := parseField(s); err == nil {
+			t.Fatalf("expected error parsing %q", s)
+		}
+	}
+}
+
+func TestCommitWitnessIndexRange(t *testing.T) {
+	if _, err := CommitWitness(`{"witness": ["0x03", "0x09"]}`, []int{2}); err == nil {
+		t.Fatalf("expected error for an out of range witness index")
+	}
+}